@@ -0,0 +1,74 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+// NewBackgroundTask creates a task that runs the given start function in the
+// background. The Execute method runs the start function in a new coroutine, and
+// returns after the start function signals the readiness by sending to or closing
+// the given ready channel. If the start function returns before the readiness, the
+// Execute method returns its error. The Shutdown method calls the given stop function,
+// which must make the start function return, then waits for the start function to
+// return, and returns the errors of both. The stop function can be nil.
+func NewBackgroundTask(start func(ready chan<- struct{}) error, stop func() error) Task {
+	return &backgroundTask{start: start, stop: stop}
+}
+
+// The backgroundTask type is the task that runs a function in the background.
+type backgroundTask struct {
+	start func(ready chan<- struct{}) error
+	stop  func() error
+	// The exited channel is closed when the start function returns.
+	exited chan struct{}
+	err    error
+}
+
+// Execute method starts the start function and waits for it to be ready.
+func (t *backgroundTask) Execute() error {
+	// The ready channel is buffered, so the start function never blocks on sending.
+	ready := make(chan struct{}, 1)
+	t.exited = make(chan struct{})
+	goManaged(func() {
+		t.err = SafeCall(func() error { return t.start(ready) })
+	}, func() { close(t.exited) })
+
+	select {
+	case <-ready:
+		return nil
+	case <-t.exited:
+		select {
+		case <-ready:
+			// The start function is ready before it returns.
+			return nil
+		default:
+			return t.err
+		}
+	}
+}
+
+// Shutdown method calls the stop function and waits for the start function to return.
+func (t *backgroundTask) Shutdown() error {
+	if t.exited == nil {
+		// The task has not been started.
+		return nil
+	}
+
+	errs := new(Errors)
+	if t.stop != nil {
+		errs.Add(SafeCall(t.stop))
+	}
+	<-t.exited
+	errs.Add(t.err)
+	return errs.result()
+}
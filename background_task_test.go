@@ -0,0 +1,72 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestRunner_RunBackground(t *testing.T) {
+	r := New()
+	stopped := make(chan struct{})
+	var ready bool
+	err := r.RunBackground(func(c chan<- struct{}) error {
+		ready = true
+		close(c)
+		<-stopped
+		return errors.New("test1")
+	}, func() error {
+		close(stopped)
+		return errors.New("test2")
+	})
+	if err != nil || !ready {
+		t.Fatalf("Runner.RunBackground(): %v %v", err, ready)
+	}
+	if err := r.Exit(); err == nil || err.Error() != "test2; test1" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+
+	// The start function returns before the readiness.
+	r = New()
+	if err := r.RunBackground(func(chan<- struct{}) error { return errors.New("test") }, nil); err == nil ||
+		err.Error() != "test" {
+		t.Fatalf("Runner.RunBackground(): %v", err)
+	}
+	if tasks := r.Tasks(); len(tasks) != 0 {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+
+	// The start function is ready and returns immediately.
+	if err := r.RunBackground(func(c chan<- struct{}) error {
+		c <- struct{}{}
+		return nil
+	}, nil); err != nil {
+		t.Fatalf("Runner.RunBackground(): %v", err)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+}
+
+func TestNewBackgroundTask(t *testing.T) {
+	task := NewBackgroundTask(func(chan<- struct{}) error { panic("test") }, nil)
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("BackgroundTask.Shutdown(): %s", err)
+	}
+	if err := task.Execute(); !IsPanicError(err) {
+		t.Fatalf("BackgroundTask.Execute(): %v", err)
+	}
+}
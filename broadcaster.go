@@ -15,7 +15,9 @@
 package runner
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 // Broadcaster interface defines the broadcaster.
@@ -28,11 +30,28 @@ type Broadcaster interface {
 	// will always return an empty waiter.
 	NewWaiter() ReceiptableWaiter
 
+	// NewWaiterContext is like NewWaiter, but if the given context is done before
+	// the waiter is acknowledged, the Done method of the waiter is called automatically
+	// and the waiter is removed from the broadcaster, so the broadcast never waits for
+	// the subscriber that has given up.
+	NewWaiterContext(context.Context) ReceiptableWaiter
+
 	// Broadcast sends a close signal to all the waiters that have been created
 	// and waits for all the waiters to call the Waiter.Done method.
 	// After this method is called, the broadcaster will return to its initial state.
 	Broadcast()
 
+	// BroadcastTimeout is like Broadcast, but it waits for the waiters to call the
+	// Waiter.Done method within the given duration in total, and returns the number
+	// of the waiters that have done so. When the duration elapses, the remaining
+	// waiters are still closed in reverse order, but they are abandoned without
+	// waiting, so a stuck subscriber can not block the broadcast forever.
+	BroadcastTimeout(time.Duration) int
+
+	// Len returns the number of the waiters of the current broadcaster that are
+	// waiting for the next broadcast.
+	Len() int
+
 	// Close closes the current broadcaster.
 	// The behavior of this method is consistent with the Broadcast method, the only
 	// difference is that after this method returns, the NewWaiter method will always
@@ -40,16 +59,44 @@ type Broadcaster interface {
 	Close()
 }
 
+// StickyBroadcaster interface defines the sticky broadcaster.
+// After a broadcast, the sticky broadcaster remembers it, and the NewWaiter method
+// always returns an already closed waiter, so the subscribers registered after the
+// broadcast can see it immediately, until the Reset method is called.
+type StickyBroadcaster interface {
+	Broadcaster
+
+	// Reset clears the sticky state of the last broadcast, so the NewWaiter method
+	// returns waiters for the next broadcast again. It is worth noting that the
+	// closed broadcaster can not be reset.
+	Reset()
+}
+
 // NewBroadcaster creates and returns a new broadcaster instance.
 func NewBroadcaster() Broadcaster {
 	return &broadcaster{}
 }
 
-// The built-in implementation of the Broadcaster interface.
+// NewStickyBroadcaster creates and returns a new sticky broadcaster instance.
+func NewStickyBroadcaster() StickyBroadcaster {
+	return &broadcaster{sticky: true}
+}
+
+// The built-in implementation of the Broadcaster and StickyBroadcaster interface.
 type broadcaster struct {
 	mutex   sync.Mutex
-	waiters []DuplexWaiter
+	waiters []*broadcastWaiter
 	closed  bool
+	sticky  bool
+	fired   bool
+}
+
+// The broadcastWaiter type is the waiter of the broadcaster.
+type broadcastWaiter struct {
+	DuplexWaiter
+	// The channel is closed when the broadcast abandons the waiter without waiting
+	// for it, so the coroutine watching the context of the waiter can exit.
+	abandoned chan struct{}
 }
 
 // NewWaiter creates and returns a new Waiter instance.
@@ -62,14 +109,57 @@ func (b *broadcaster) NewWaiter() ReceiptableWaiter {
 	b.mutex.Lock()
 	defer b.mutex.Unlock()
 
-	if b.closed {
+	if b.closed || b.fired {
 		return EmptyReceiptableWaiter()
 	}
-	w := NewDuplexWaiter()
+	w := &broadcastWaiter{DuplexWaiter: NewDuplexWaiter()}
 	b.waiters = append(b.waiters, w)
 	return w.Waiter()
 }
 
+// NewWaiterContext is like NewWaiter, but if the given context is done before
+// the waiter is acknowledged, the Done method of the waiter is called automatically
+// and the waiter is removed from the broadcaster, so the broadcast never waits for
+// the subscriber that has given up.
+func (b *broadcaster) NewWaiterContext(ctx context.Context) ReceiptableWaiter {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.closed || b.fired {
+		return EmptyReceiptableWaiter()
+	}
+	w := &broadcastWaiter{DuplexWaiter: NewDuplexWaiter(), abandoned: make(chan struct{})}
+	b.waiters = append(b.waiters, w)
+	go b.watch(ctx, w)
+	return w.Waiter()
+}
+
+// Watch the given context until the given waiter is acknowledged or abandoned.
+func (b *broadcaster) watch(ctx context.Context, w *broadcastWaiter) {
+	select {
+	case <-w.DoneChannel():
+	case <-w.abandoned:
+	case <-ctx.Done():
+		// The waiter is acknowledged before removing it, because the broadcast in
+		// progress holds the lock while waiting for the acknowledgment.
+		w.Done()
+		b.remove(w)
+	}
+}
+
+// Remove the given waiter from the current broadcaster.
+func (b *broadcaster) remove(w *broadcastWaiter) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for i := range b.waiters {
+		if b.waiters[i] == w {
+			b.waiters = append(b.waiters[:i:i], b.waiters[i+1:]...)
+			return
+		}
+	}
+}
+
 // Broadcast sends a close signal to all the waiters that have been created
 // and waits for all the waiters to call the Waiter.Done method.
 // After this method is called, the broadcaster will return to its initial state.
@@ -78,6 +168,28 @@ func (b *broadcaster) Broadcast() {
 	defer b.mutex.Unlock()
 
 	b.close()
+	b.fired = b.sticky
+}
+
+// BroadcastTimeout is like Broadcast, but it waits for the waiters to call the
+// Waiter.Done method within the given duration in total, and returns the number
+// of the waiters that have done so.
+func (b *broadcaster) BroadcastTimeout(d time.Duration) int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	n := b.closeTimeout(d)
+	b.fired = b.sticky
+	return n
+}
+
+// Len returns the number of the waiters of the current broadcaster that are
+// waiting for the next broadcast.
+func (b *broadcaster) Len() int {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return len(b.waiters)
 }
 
 // Close closes the current broadcaster.
@@ -92,6 +204,16 @@ func (b *broadcaster) Close() {
 	b.close()
 }
 
+// Reset clears the sticky state of the last broadcast, so the NewWaiter method
+// returns waiters for the next broadcast again. It is worth noting that the
+// closed broadcaster can not be reset.
+func (b *broadcaster) Reset() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.fired = false
+}
+
 // Close all the waiters in the current broadcaster in reverse order.
 func (b *broadcaster) close() {
 	if n := len(b.waiters); n > 0 {
@@ -101,3 +223,44 @@ func (b *broadcaster) close() {
 		b.waiters = nil
 	}
 }
+
+// Close all the waiters in the current broadcaster in reverse order, and wait for
+// them to be done within the given duration, returns the number of the done waiters.
+// After the duration elapses, the remaining waiters are closed without waiting.
+func (b *broadcaster) closeTimeout(d time.Duration) int {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	var n int
+	var expired bool
+	for i := len(b.waiters) - 1; i >= 0; i-- {
+		w := b.waiters[i]
+		w.Close()
+		if expired {
+			// The waiter that is done immediately is still counted.
+			select {
+			case <-w.DoneChannel():
+				n++
+			default:
+				w.abandon()
+			}
+			continue
+		}
+		select {
+		case <-w.DoneChannel():
+			n++
+		case <-timer.C:
+			expired = true
+			w.abandon()
+		}
+	}
+	b.waiters = nil
+	return n
+}
+
+// Abandon the current waiter, the waiter created without a context has nothing to stop.
+func (w *broadcastWaiter) abandon() {
+	if w.abandoned != nil {
+		close(w.abandoned)
+	}
+}
@@ -15,8 +15,11 @@
 package runner
 
 import (
+	"context"
+	"runtime"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestBroadcaster(t *testing.T) {
@@ -70,3 +73,194 @@ func TestBroadcaster(t *testing.T) {
 		t.Fatalf("Broadcaster.NewWaiter(): %s", got)
 	}
 }
+
+func TestBroadcaster_BroadcastTimeout(t *testing.T) {
+	b := NewBroadcaster()
+
+	// The stuck waiter never calls the Done method.
+	stuck := b.NewWaiter()
+	ss := make([]string, 0)
+	for _, s := range []string{"A", "B"} {
+		go func(s string, w ReceiptableWaiter) {
+			defer w.Done()
+			w.Wait()
+			ss = append(ss, s)
+		}(s, b.NewWaiter())
+	}
+
+	if n := b.BroadcastTimeout(time.Millisecond * 50); n != 2 {
+		t.Fatalf("Broadcaster.BroadcastTimeout(): %d", n)
+	}
+	if got := strings.Join(ss, "-"); got != "B-A" {
+		t.Fatalf("Broadcaster.BroadcastTimeout(): %s", got)
+	}
+	select {
+	case <-stuck.Channel():
+	default:
+		t.Fatal("Broadcaster.BroadcastTimeout(): the stuck waiter is not closed")
+	}
+
+	// The broadcaster returns to its initial state.
+	if n := b.BroadcastTimeout(time.Millisecond); n != 0 {
+		t.Fatalf("Broadcaster.BroadcastTimeout(): %d", n)
+	}
+	w := b.NewWaiter()
+	go w.Done()
+	if n := b.BroadcastTimeout(time.Hour); n != 1 {
+		t.Fatalf("Broadcaster.BroadcastTimeout(): %d", n)
+	}
+}
+
+func TestBroadcaster_Len(t *testing.T) {
+	b := NewBroadcaster()
+	if n := b.Len(); n != 0 {
+		t.Fatalf("Broadcaster.Len(): %d", n)
+	}
+
+	for i := 0; i < 3; i++ {
+		go func(w ReceiptableWaiter) {
+			defer w.Done()
+			w.Wait()
+		}(b.NewWaiter())
+	}
+	if n := b.Len(); n != 3 {
+		t.Fatalf("Broadcaster.Len(): %d", n)
+	}
+
+	b.Broadcast()
+	if n := b.Len(); n != 0 {
+		t.Fatalf("Broadcaster.Len(): %d", n)
+	}
+
+	// The waiter whose context is done is removed.
+	ctx, cancel := context.WithCancel(context.Background())
+	w := b.NewWaiterContext(ctx)
+	if n := b.Len(); n != 1 {
+		t.Fatalf("Broadcaster.Len(): %d", n)
+	}
+	cancel()
+	for i := 0; i < 100 && b.Len() != 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := b.Len(); n != 0 {
+		t.Fatalf("Broadcaster.Len(): %d", n)
+	}
+	w.Done()
+
+	b.Close()
+	b.NewWaiter()
+	if n := b.Len(); n != 0 {
+		t.Fatalf("Broadcaster.Len(): %d", n)
+	}
+}
+
+func TestStickyBroadcaster(t *testing.T) {
+	b := NewStickyBroadcaster()
+	if b == nil {
+		t.Fatal("NewStickyBroadcaster(): nil")
+	}
+
+	ss := make([]string, 0)
+	go func(w ReceiptableWaiter) {
+		defer w.Done()
+		w.Wait()
+		ss = append(ss, "A")
+	}(b.NewWaiter())
+
+	b.Broadcast()
+	if got := strings.Join(ss, "-"); got != "A" {
+		t.Fatalf("StickyBroadcaster.Broadcast(): %s", got)
+	}
+
+	// The late subscriber sees the last broadcast immediately.
+	select {
+	case <-b.NewWaiter().Channel():
+	default:
+		t.Fatal("StickyBroadcaster.NewWaiter(): not closed")
+	}
+
+	b.Reset()
+	w := b.NewWaiter()
+	select {
+	case <-w.Channel():
+		t.Fatal("StickyBroadcaster.NewWaiter(): closed")
+	default:
+	}
+
+	go func(w ReceiptableWaiter) {
+		defer w.Done()
+		w.Wait()
+		ss = append(ss, "B")
+	}(w)
+
+	b.Close()
+	b.Reset()
+	if got := strings.Join(ss, "-"); got != "A-B" {
+		t.Fatalf("StickyBroadcaster.Close(): %s", got)
+	}
+	select {
+	case <-b.NewWaiter().Channel():
+	default:
+		t.Fatal("StickyBroadcaster.NewWaiter(): not closed")
+	}
+}
+
+func TestBroadcaster_NewWaiterContext(t *testing.T) {
+	base := runtime.NumGoroutine()
+	b := NewBroadcaster()
+
+	// The context is canceled before the broadcast.
+	ctx, cancel := context.WithCancel(context.Background())
+	b.NewWaiterContext(ctx)
+	cancel()
+	for {
+		b.(*broadcaster).mutex.Lock()
+		n := len(b.(*broadcaster).waiters)
+		b.(*broadcaster).mutex.Unlock()
+		if n == 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	b.Broadcast()
+
+	// The broadcast is acknowledged before the context is canceled.
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	w := b.NewWaiterContext(ctx)
+	go func() {
+		w.Wait()
+		w.Done()
+	}()
+	b.Broadcast()
+
+	// The context is canceled while the broadcast is waiting for the acknowledgment.
+	ctx2, cancel2 := context.WithCancel(context.Background())
+	w = b.NewWaiterContext(ctx2)
+	go func() {
+		w.Wait()
+		cancel2()
+	}()
+	b.Broadcast()
+
+	// The waiters that never acknowledge are abandoned by the timed out broadcast.
+	b.NewWaiterContext(context.Background())
+	b.NewWaiterContext(context.Background())
+	if n := b.BroadcastTimeout(time.Millisecond * 10); n != 0 {
+		t.Fatalf("Broadcaster.BroadcastTimeout(): %d", n)
+	}
+
+	b.Close()
+	if w := b.NewWaiterContext(context.Background()); w != EmptyReceiptableWaiter() {
+		t.Fatal("Broadcaster.NewWaiterContext(): not empty")
+	}
+
+	// All the watcher coroutines exit.
+	deadline := time.Now().Add(time.Second * 5)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("Broadcaster.NewWaiterContext(): %d > %d", runtime.NumGoroutine(), base)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -15,19 +15,47 @@
 package runner
 
 import (
+	"context"
 	"fmt"
+	"runtime/debug"
+	"sync/atomic"
 )
 
+// Whether to append the captured stack to the message of the PanicError.
+var verbosePanicError int32
+
+// SetVerbosePanicError function enables or disables the verbose message of the
+// PanicError. When enabled, the Error method of the PanicError appends the captured
+// stack (see the SafeCallWithStack function) to the message, if any.
+func SetVerbosePanicError(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&verbosePanicError, 1)
+	} else {
+		atomic.StoreInt32(&verbosePanicError, 0)
+	}
+}
+
 // PanicError defines the panic error captured by recover.
 // We do not recommend using this error type in application business logic.
 // The purpose of designing this error type is to ensure that the SafeCall
 // function can report panic.
 type PanicError struct {
-	v interface{}
+	v     interface{}
+	stack []byte
 }
 
 // Error method is an implementation of the error interface.
+// If the verbose message is enabled by the SetVerbosePanicError function, the
+// captured stack is appended to the message on a new line.
 func (e *PanicError) Error() string {
+	if e.stack != nil && atomic.LoadInt32(&verbosePanicError) == 1 {
+		return e.message() + "\n" + string(e.stack)
+	}
+	return e.message()
+}
+
+// Returns the message of the recovered value.
+func (e *PanicError) message() string {
 	switch o := e.v.(type) {
 	case string:
 		return o
@@ -39,6 +67,19 @@ func (e *PanicError) Error() string {
 	return fmt.Sprintf("panic: %v", e.v)
 }
 
+// Value method returns the original value recovered from the panic, so the typed
+// panic payload can be inspected without matching the error message.
+func (e *PanicError) Value() interface{} {
+	return e.v
+}
+
+// Stack method returns the stack of the panicking coroutine.
+// The stack is only captured by the SafeCallWithStack and Go functions, otherwise
+// it returns nil.
+func (e *PanicError) Stack() []byte {
+	return e.stack
+}
+
 // IsPanicError determines whether the given error is a PanicError.
 // If the given error is nil, it always returns false.
 func IsPanicError(err error) (ok bool) {
@@ -61,6 +102,36 @@ func SafeCall(f func() error) (err error) {
 	return
 }
 
+// SafeCall2 is like SafeCall, but it returns the error returned by the given function
+// and the captured panic separately, so the panic never replaces the error.
+// It is worth noting that if the given function panics (including the panic raised by
+// its deferred functions after a return statement), the function never returns to the
+// caller, so its return value is not available and the returned error is nil.
+func SafeCall2(f func() error) (err error, pe *PanicError) {
+	defer func() {
+		if v := recover(); v != nil {
+			pe = &PanicError{v: v}
+		}
+	}()
+	err = f()
+	return
+}
+
+// SafeCallWithStack is like SafeCall, but the stack of the current coroutine is
+// captured when recovering, which can be obtained by the PanicError.Stack method.
+// Since the recover happens in the panicking coroutine, the stack contains the frames
+// of the panicking function. Capturing the stack is expensive, so the SafeCall
+// function does not capture it.
+func SafeCallWithStack(f func() error) (err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			err = &PanicError{v: v, stack: debug.Stack()}
+		}
+	}()
+	err = f()
+	return
+}
+
 // MustCall executes the given function immediately, and panic immediately
 // if the given function returns a non-nil error.
 func MustCall(f func() error) {
@@ -76,8 +147,27 @@ func MustCall(f func() error) {
 
 // Go is like SafeCall, but it is executed asynchronously.
 // The returned read-only channel is used to get the error when the given function exits.
+// If the given function panics, the returned PanicError contains the stack of the
+// panicking coroutine.
 func Go(f func() error) <-chan error {
 	c := make(chan error, 1)
-	go func(c chan error) { c <- SafeCall(f) }(c)
+	go func(c chan error) { c <- SafeCallWithStack(f) }(c)
 	return c
 }
+
+// SafeCallContext is like SafeCall, but the given function is executed in a new
+// coroutine with the given context, and if the given context is done before the
+// function returns, the error of the context is returned. In this case, the function
+// keeps running in its coroutine until it returns, so it should observe the context.
+// If the given context is already done, the function is not called.
+func SafeCallContext(ctx context.Context, f func(context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	select {
+	case err := <-Go(func() error { return f(ctx) }):
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
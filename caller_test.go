@@ -15,8 +15,11 @@
 package runner
 
 import (
+	"context"
 	"errors"
+	"strings"
 	"testing"
+	"time"
 )
 
 func TestIsPanicError(t *testing.T) {
@@ -104,10 +107,10 @@ func TestPanicError_Error(t *testing.T) {
 		Err  *PanicError
 		Want string
 	}{
-		{&PanicError{"test1"}, "test1"},
-		{&PanicError{errors.New("test2")}, "test2"},
-		{&PanicError{testFmtStringerForPanicError("test3")}, "test3"},
-		{&PanicError{4}, "panic: 4"},
+		{&PanicError{v: "test1"}, "test1"},
+		{&PanicError{v: errors.New("test2")}, "test2"},
+		{&PanicError{v: testFmtStringerForPanicError("test3")}, "test3"},
+		{&PanicError{v: 4}, "panic: 4"},
 	}
 
 	for i, item := range items {
@@ -117,6 +120,20 @@ func TestPanicError_Error(t *testing.T) {
 	}
 }
 
+func TestPanicError_Value(t *testing.T) {
+	type payload struct{ code int }
+
+	err := SafeCall(func() error { panic(payload{code: 1}) })
+	if v, ok := err.(*PanicError).Value().(payload); !ok || v.code != 1 {
+		t.Fatalf("PanicError.Value(): %v", err.(*PanicError).Value())
+	}
+
+	want := errors.New("test")
+	if v := SafeCall(func() error { panic(want) }).(*PanicError).Value(); v != want {
+		t.Fatalf("PanicError.Value(): %v", v)
+	}
+}
+
 func TestGo(t *testing.T) {
 	want := errors.New("test")
 	got := <-Go(func() error {
@@ -127,3 +144,96 @@ func TestGo(t *testing.T) {
 		t.Fatalf("Go(): %s", got)
 	}
 }
+
+func testPanicWorkerForGo() error {
+	panic("test")
+}
+
+func TestGo_PanicStack(t *testing.T) {
+	err := <-Go(testPanicWorkerForGo)
+	e, ok := err.(*PanicError)
+	if !ok {
+		t.Fatalf("Go(): %v", err)
+	}
+	if s := string(e.Stack()); !strings.Contains(s, "testPanicWorkerForGo") {
+		t.Fatalf("PanicError.Stack(): %s", s)
+	}
+
+	if s := SafeCall(testPanicWorkerForGo).(*PanicError).Stack(); s != nil {
+		t.Fatalf("PanicError.Stack(): %s", s)
+	}
+}
+
+func TestSafeCallWithStack(t *testing.T) {
+	want := errors.New("test")
+	if err := SafeCallWithStack(func() error { return want }); err != want {
+		t.Fatalf("SafeCallWithStack(): %v", err)
+	}
+
+	err := SafeCallWithStack(testPanicWorkerForGo)
+	e, ok := err.(*PanicError)
+	if !ok || !strings.Contains(string(e.Stack()), "testPanicWorkerForGo") {
+		t.Fatalf("SafeCallWithStack(): %v", err)
+	}
+	if s := e.Error(); s != "test" {
+		t.Fatalf("PanicError.Error(): %s", s)
+	}
+
+	SetVerbosePanicError(true)
+	defer SetVerbosePanicError(false)
+	if s := e.Error(); !strings.HasPrefix(s, "test\n") || !strings.Contains(s, "testPanicWorkerForGo") {
+		t.Fatalf("PanicError.Error(): %s", s)
+	}
+	// The panic error without the stack is not affected.
+	if s := SafeCall(testPanicWorkerForGo).Error(); s != "test" {
+		t.Fatalf("PanicError.Error(): %s", s)
+	}
+}
+
+func TestSafeCall2(t *testing.T) {
+	want := errors.New("test")
+	if err, pe := SafeCall2(func() error { return want }); err != want || pe != nil {
+		t.Fatalf("SafeCall2(): %v %v", err, pe)
+	}
+	if err, pe := SafeCall2(func() error { panic("test") }); err != nil || pe == nil || pe.Error() != "test" {
+		t.Fatalf("SafeCall2(): %v %v", err, pe)
+	}
+
+	// The deferred function panics after the return value is set.
+	err, pe := SafeCall2(func() (err error) {
+		defer func() { panic("cleanup") }()
+		return want
+	})
+	if err != nil || pe == nil || pe.Error() != "cleanup" {
+		t.Fatalf("SafeCall2(): %v %v", err, pe)
+	}
+}
+
+func TestSafeCallContext(t *testing.T) {
+	want := errors.New("test")
+	if err := SafeCallContext(context.Background(), func(context.Context) error { return want }); err != want {
+		t.Fatalf("SafeCallContext(): %v", err)
+	}
+	if err := SafeCallContext(context.Background(), func(context.Context) error { panic("test") }); !IsPanicError(err) {
+		t.Fatalf("SafeCallContext(): %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	release := make(chan struct{})
+	defer close(release)
+	if err := SafeCallContext(ctx, func(context.Context) error {
+		<-release
+		return nil
+	}); err != context.DeadlineExceeded {
+		t.Fatalf("SafeCallContext(): %v", err)
+	}
+
+	var called bool
+	if err := SafeCallContext(ctx, func(context.Context) error {
+		called = true
+		return nil
+	}); err != context.DeadlineExceeded || called {
+		t.Fatalf("SafeCallContext(): %v %v", err, called)
+	}
+}
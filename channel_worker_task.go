@@ -0,0 +1,114 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package runner
+
+// NewChannelWorkerTask creates a task that consumes the messages of the given channel
+// with the given handle function, as the "for msg := range ch" worker loop does.
+// The Execute method starts the consume coroutine, which exits when the channel is
+// closed or the task is shut down. The Shutdown method stops the consume coroutine
+// and waits for it to exit. If drain is true, the messages buffered in the channel
+// when the task is shut down are handled before the coroutine exits, otherwise they
+// are left in the channel. The panics of the handle function are recovered, and if
+// the optional onPanic function is given, they are reported to it.
+func NewChannelWorkerTask[T any](ch <-chan T, handle func(T), drain bool, onPanic ...func(error)) Task {
+	t := &channelWorkerTask[T]{ch: ch, handle: handle, drain: drain}
+	switch len(onPanic) {
+	case 0:
+	case 1:
+		t.onPanic = onPanic[0]
+	default:
+		panic("NewChannelWorkerTask(): too many panic handlers.")
+	}
+	return t
+}
+
+// The channelWorkerTask type is the task that consumes the messages of a channel.
+type channelWorkerTask[T any] struct {
+	ch      <-chan T
+	handle  func(T)
+	drain   bool
+	onPanic func(error)
+	waiter  DuplexWaiter
+}
+
+// Execute method starts the consume coroutine.
+func (t *channelWorkerTask[T]) Execute() error {
+	t.waiter = NewDuplexWaiter()
+	goManaged(t.consume, t.waiter.Done)
+	return nil
+}
+
+// Shutdown method stops the consume coroutine and waits for it to exit.
+func (t *channelWorkerTask[T]) Shutdown() error {
+	if t.waiter != nil {
+		t.waiter.CloseAndWaitDone()
+	}
+	return nil
+}
+
+// Handles the messages of the channel until the channel is closed or the task is
+// shut down.
+func (t *channelWorkerTask[T]) consume() {
+	stop := t.waiter.Channel()
+	for {
+		// The shutdown takes precedence over the pending messages.
+		select {
+		case <-stop:
+			t.stop()
+			return
+		default:
+		}
+
+		select {
+		case <-stop:
+			t.stop()
+			return
+		case v, ok := <-t.ch:
+			if !ok {
+				return
+			}
+			t.call(v)
+		}
+	}
+}
+
+// Handles the messages buffered in the channel when the task is shut down if the
+// drain is required. The messages sent after the shutdown are not handled, so the
+// producers can not keep the coroutine alive.
+func (t *channelWorkerTask[T]) stop() {
+	if !t.drain {
+		return
+	}
+	for n := len(t.ch); n > 0; n-- {
+		v, ok := <-t.ch
+		if !ok {
+			return
+		}
+		t.call(v)
+	}
+}
+
+// Handles the given message with panic protection.
+func (t *channelWorkerTask[T]) call(v T) {
+	if err := SafeCall(func() error {
+		t.handle(v)
+		return nil
+	}); err != nil && t.onPanic != nil {
+		t.onPanic(err)
+	}
+}
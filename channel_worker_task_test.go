@@ -0,0 +1,115 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package runner
+
+import (
+	"testing"
+)
+
+func TestNewChannelWorkerTask(t *testing.T) {
+	ch := make(chan int)
+	handled := make(chan int, 10)
+	var panics []error
+	task := NewChannelWorkerTask(ch, func(v int) {
+		if v < 0 {
+			panic("test")
+		}
+		handled <- v
+	}, false, func(err error) { panics = append(panics, err) })
+
+	if err := task.Execute(); err != nil {
+		t.Fatalf("ChannelWorkerTask.Execute(): %s", err)
+	}
+	ch <- 1
+	ch <- -1
+	ch <- 2
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("ChannelWorkerTask.Shutdown(): %s", err)
+	}
+	close(handled)
+
+	var got []int
+	for v := range handled {
+		got = append(got, v)
+	}
+	if len(got) != 2 || got[0] != 1 || got[1] != 2 {
+		t.Fatalf("ChannelWorkerTask: %v", got)
+	}
+	if len(panics) != 1 || !IsPanicError(panics[0]) {
+		t.Fatalf("ChannelWorkerTask: %v", panics)
+	}
+}
+
+func TestNewChannelWorkerTask_Drain(t *testing.T) {
+	for _, drain := range []bool{true, false} {
+		ch := make(chan int, 3)
+		var got []int
+		task := NewChannelWorkerTask(ch, func(v int) { got = append(got, v) }, drain)
+		// The messages are buffered before the consume coroutine is started, and the
+		// coroutine is stopped immediately, so only the drain handles them.
+		ch <- 1
+		ch <- 2
+		ch <- 3
+		w := task.(*channelWorkerTask[int])
+		w.waiter = NewDuplexWaiter()
+		w.waiter.Close()
+		w.consume()
+
+		if drain && len(got) != 3 {
+			t.Fatalf("ChannelWorkerTask: drain %v", got)
+		}
+		if !drain && (len(got) != 0 || len(ch) != 3) {
+			t.Fatalf("ChannelWorkerTask: no drain %v", got)
+		}
+	}
+}
+
+func TestNewChannelWorkerTask_ClosedChannel(t *testing.T) {
+	ch := make(chan int)
+	task := NewChannelWorkerTask(ch, func(int) {}, true)
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("ChannelWorkerTask.Shutdown(): %s", err)
+	}
+	if err := task.Execute(); err != nil {
+		t.Fatalf("ChannelWorkerTask.Execute(): %s", err)
+	}
+	close(ch)
+	task.(*channelWorkerTask[int]).waiter.WaitDone()
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("ChannelWorkerTask.Shutdown(): %s", err)
+	}
+}
+
+func TestNewChannelWorkerTask_Panic(t *testing.T) {
+	ch := make(chan int, 1)
+	ch <- 1
+	task := NewChannelWorkerTask(ch, func(int) { panic("test") }, true)
+	if err := task.Execute(); err != nil {
+		t.Fatalf("ChannelWorkerTask.Execute(): %s", err)
+	}
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("ChannelWorkerTask.Shutdown(): %s", err)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("NewChannelWorkerTask(): no panic")
+		}
+	}()
+	NewChannelWorkerTask(ch, func(int) {}, true, func(error) {}, func(error) {})
+}
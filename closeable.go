@@ -14,6 +14,21 @@
 
 package runner
 
+import (
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	// Whether to diagnose the double close of the safe closeable resources.
+	debugDoubleClose int32
+
+	// The logger of the double close diagnostics.
+	closeableMutex         = new(sync.RWMutex)
+	closeableLogger Logger = stdLogger{}
+)
+
 // Closeable interface defines the closeable resource.
 // The difference between Closeable and io.Closer is that the resource close
 // does not return an error.
@@ -29,3 +44,62 @@ type CloseableFunc func()
 func (f CloseableFunc) Close() {
 	f()
 }
+
+// SetDebugDoubleClose function enables or disables the double close diagnostics.
+// When enabled, closing an already closed safe closeable resource (see the
+// NewSafeCloseable function) logs a warning with the stack, and a panic raised by
+// closing the underlying resource is logged with the stack instead of propagating.
+// The diagnostics are written to the logger set by the SetCloseableLogger function.
+func SetDebugDoubleClose(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&debugDoubleClose, 1)
+	} else {
+		atomic.StoreInt32(&debugDoubleClose, 0)
+	}
+}
+
+// SetCloseableLogger function sets the logger of the double close diagnostics, see
+// the SetDebugDoubleClose function. By default, the diagnostics are written to the
+// standard logger of the log package.
+func SetCloseableLogger(logger Logger) {
+	closeableMutex.Lock()
+	closeableLogger = logger
+	closeableMutex.Unlock()
+}
+
+// Returns the logger set by the SetCloseableLogger function.
+func getCloseableLogger() Logger {
+	closeableMutex.RLock()
+	defer closeableMutex.RUnlock()
+	return closeableLogger
+}
+
+// NewSafeCloseable creates and returns an idempotent closeable resource wrapping the
+// given closeable resource, only the first Close call closes the given resource.
+func NewSafeCloseable(c Closeable) Closeable {
+	return &safeCloseable{c: c}
+}
+
+// The safeCloseable type is the idempotent wrapper of the closeable resource.
+type safeCloseable struct {
+	c      Closeable
+	closed int32
+}
+
+// Close close the current resource. This method is idempotent.
+func (s *safeCloseable) Close() {
+	if !atomic.CompareAndSwapInt32(&s.closed, 0, 1) {
+		if atomic.LoadInt32(&debugDoubleClose) == 1 {
+			getCloseableLogger().Printf("runner: close an already closed resource\n%s", debug.Stack())
+		}
+		return
+	}
+	if atomic.LoadInt32(&debugDoubleClose) == 1 {
+		defer func() {
+			if v := recover(); v != nil {
+				getCloseableLogger().Printf("runner: close panic: %v\n%s", v, debug.Stack())
+			}
+		}()
+	}
+	s.c.Close()
+}
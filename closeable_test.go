@@ -0,0 +1,72 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestNewSafeCloseable(t *testing.T) {
+	c := make(chan struct{})
+	s := NewSafeCloseable(CloseableFunc(func() { close(c) }))
+	s.Close()
+	s.Close()
+	<-c
+}
+
+func TestSetDebugDoubleClose(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+
+	SetDebugDoubleClose(true)
+	defer SetDebugDoubleClose(false)
+
+	c := make(chan struct{})
+	s := NewSafeCloseable(CloseableFunc(func() { close(c) }))
+	s.Close()
+	s.Close()
+	if s := buf.String(); !strings.Contains(s, "runner: close an already closed resource") ||
+		!strings.Contains(s, "TestSetDebugDoubleClose") {
+		t.Fatalf("SetDebugDoubleClose(): %s", s)
+	}
+
+	// The underlying resource is closed through another path.
+	buf.Reset()
+	NewSafeCloseable(CloseableFunc(func() { close(c) })).Close()
+	if s := buf.String(); !strings.Contains(s, "runner: close panic: close of closed channel") {
+		t.Fatalf("SetDebugDoubleClose(): %s", s)
+	}
+}
+
+func TestSetCloseableLogger(t *testing.T) {
+	logger := new(testLogger)
+	SetCloseableLogger(logger)
+	defer SetCloseableLogger(stdLogger{})
+
+	SetDebugDoubleClose(true)
+	defer SetDebugDoubleClose(false)
+
+	s := NewSafeCloseable(CloseableFunc(func() {}))
+	s.Close()
+	s.Close()
+	if ms := logger.Messages(); len(ms) != 1 || !strings.HasPrefix(ms[0], "runner: close an already closed resource") {
+		t.Fatalf("SetCloseableLogger(): %v", ms)
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// CombineReceiptable combines the given receiptable waiters into one receiptable waiter.
+// The returned waiter is closed after all the given waiters are closed, and its Done
+// method forwards the acknowledgment to all the given waiters. This allows a coordinator
+// subscribing to multiple broadcasters to represent them as one subscriber.
+// If no waiter is given, an empty receiptable waiter is returned.
+func CombineReceiptable(ws ...ReceiptableWaiter) ReceiptableWaiter {
+	if len(ws) == 0 {
+		return EmptyReceiptableWaiter()
+	}
+	w := &combinedReceiptableWaiter{channelWaiter: newChannelWaiter(), ws: ws}
+	go w.watch()
+	return w
+}
+
+// The combinedReceiptableWaiter type is the combination of multiple receiptable waiters.
+type combinedReceiptableWaiter struct {
+	*channelWaiter
+	ws   []ReceiptableWaiter
+	once sync.Once
+}
+
+// Wait for all the combined waiters to be closed, then close the current waiter.
+func (w *combinedReceiptableWaiter) watch() {
+	for i := range w.ws {
+		w.ws[i].Wait()
+	}
+	close(w.c)
+}
+
+// Done reports to all the combined waiters that the current waiter has completed.
+func (w *combinedReceiptableWaiter) Done() { w.once.Do(w.done) }
+
+func (w *combinedReceiptableWaiter) done() {
+	for i := range w.ws {
+		w.ws[i].Done()
+	}
+}
+
+// WaitAll returns a waiter that is closed after all the given waiters are closed.
+// The returned waiter can also be closed early to abandon the waiting, closing it
+// never closes the given waiters, and the watcher coroutine exits immediately.
+// If no waiter is given, the returned waiter is already closed.
+func WaitAll(ws ...Waiter) CloseableWaiter {
+	w := newCloseableWaiter()
+	if len(ws) == 0 {
+		w.Close()
+		return w
+	}
+	go func() {
+		for i := range ws {
+			select {
+			case <-ws[i].Channel():
+			case <-w.Channel():
+				return
+			}
+		}
+		w.Close()
+	}()
+	return w
+}
+
+// WaitAny returns a waiter that is closed after any of the given waiters is closed.
+// The returned waiter can also be closed early to abandon the waiting, closing it
+// never closes the given waiters, and all the watcher coroutines exit immediately.
+// If no waiter is given, the returned waiter is only closed by its Close method.
+func WaitAny(ws ...Waiter) CloseableWaiter {
+	w := newCloseableWaiter()
+	for i := range ws {
+		go func(c <-chan struct{}) {
+			select {
+			case <-c:
+				w.Close()
+			case <-w.Channel():
+			}
+		}(ws[i].Channel())
+	}
+	return w
+}
+
+// WaitAllTimeout waits for all the given waiters to be closed within the given duration.
+// It returns true if all waiters are closed in time, otherwise returns false.
+// If no waiter is given, it returns true immediately.
+// No coroutine is started, so nothing is leaked after this function returns.
+func WaitAllTimeout(d time.Duration, ws ...Waiter) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	for i := range ws {
+		c := ws[i].Channel()
+		// The closed waiter takes precedence over the expired timer.
+		select {
+		case <-c:
+			continue
+		default:
+		}
+		select {
+		case <-c:
+		case <-timer.C:
+			return false
+		}
+	}
+	return true
+}
+
+// WaitAllContext waits for all the given waiters to be closed before the given
+// context is done. It returns nil if all waiters are closed, otherwise returns
+// the error of the given context. If no waiter is given, it returns nil immediately.
+// No coroutine is started, so nothing is leaked after this function returns.
+func WaitAllContext(ctx context.Context, ws ...Waiter) error {
+	for i := range ws {
+		if err := waitContext(ctx, ws[i].Channel()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
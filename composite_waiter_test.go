@@ -0,0 +1,214 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCombineReceiptable(t *testing.T) {
+	b1 := NewBroadcaster()
+	b2 := NewBroadcaster()
+	w := CombineReceiptable(b1.NewWaiter(), b2.NewWaiter())
+
+	var n int
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w.Wait()
+		n++
+		w.Done()
+		w.Done()
+	}()
+
+	b1Done := make(chan struct{})
+	go func() {
+		defer close(b1Done)
+		b1.Broadcast()
+	}()
+
+	select {
+	case <-b1Done:
+		t.Fatal("Broadcaster.Broadcast(): not blocked")
+	case <-w.Channel():
+		t.Fatal("CombineReceiptable(): closed")
+	default:
+	}
+
+	b2.Broadcast()
+	<-b1Done
+	<-done
+
+	if n != 1 {
+		t.Fatalf("CombineReceiptable(): %d", n)
+	}
+}
+
+func TestCombineReceiptable_Empty(t *testing.T) {
+	w := CombineReceiptable()
+	if w != EmptyReceiptableWaiter() {
+		t.Fatal("CombineReceiptable(): not empty waiter")
+	}
+	w.Wait()
+	w.Done()
+}
+
+func TestCombineReceiptable_Closed(t *testing.T) {
+	w1 := NewDuplexWaiter()
+	w2 := NewDuplexWaiter()
+	w1.Close()
+	w2.Close()
+
+	w := CombineReceiptable(w1.Waiter(), w2.Waiter(), EmptyReceiptableWaiter())
+	w.Wait()
+
+	wg := new(sync.WaitGroup)
+	wg.Add(2)
+	go func() { defer wg.Done(); w1.WaitDone() }()
+	go func() { defer wg.Done(); w2.WaitDone() }()
+	w.Done()
+	wg.Wait()
+}
+
+func TestWaitAllTimeout(t *testing.T) {
+	if !WaitAllTimeout(time.Millisecond) {
+		t.Fatal("WaitAllTimeout(): false")
+	}
+
+	w1 := NewCloseableWaiter()
+	w2 := NewCloseableWaiter()
+	w1.Close()
+	if WaitAllTimeout(time.Millisecond*10, w1, w2) {
+		t.Fatal("WaitAllTimeout(): true")
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		w2.Close()
+	}()
+	if !WaitAllTimeout(time.Second, w1, w2, EmptyReceiptableWaiter()) {
+		t.Fatal("WaitAllTimeout(): false")
+	}
+	// The closed waiters take precedence over the expired timer.
+	for i := 0; i < 100; i++ {
+		if !WaitAllTimeout(0, w1, w2) {
+			t.Fatal("WaitAllTimeout(): false")
+		}
+	}
+}
+
+func TestWaitAllContext(t *testing.T) {
+	if err := WaitAllContext(context.Background()); err != nil {
+		t.Fatalf("WaitAllContext(): %s", err)
+	}
+
+	w1 := NewCloseableWaiter()
+	w2 := NewCloseableWaiter()
+	w1.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := WaitAllContext(ctx, w1, w2); err != context.DeadlineExceeded {
+		t.Fatalf("WaitAllContext(): %v", err)
+	}
+
+	w2.Close()
+	if err := WaitAllContext(context.Background(), w1, w2); err != nil {
+		t.Fatalf("WaitAllContext(): %s", err)
+	}
+	// The closed waiters take precedence over the done context.
+	<-ctx.Done()
+	for i := 0; i < 100; i++ {
+		if err := WaitAllContext(ctx, w1, w2); err != nil {
+			t.Fatalf("WaitAllContext(): %s", err)
+		}
+	}
+}
+
+func TestWaitAll(t *testing.T) {
+	// The waiter is closed before returning if no waiter is given.
+	select {
+	case <-WaitAll().Channel():
+	default:
+		t.Fatal("WaitAll(): not closed")
+	}
+
+	w1 := NewCloseableWaiter()
+	w2 := NewCloseableWaiter()
+	w := WaitAll(w1, w2)
+
+	w1.Close()
+	select {
+	case <-w.Channel():
+		t.Fatal("WaitAll(): closed")
+	case <-time.After(time.Millisecond * 10):
+	}
+	w2.Close()
+	w.Wait()
+
+	// Close the composite early.
+	w3 := NewCloseableWaiter()
+	w = WaitAll(w3)
+	w.Close()
+	w.Close()
+	w.Wait()
+	select {
+	case <-w3.Channel():
+		t.Fatal("WaitAll(): source closed")
+	default:
+	}
+}
+
+func TestWaitAny(t *testing.T) {
+	w1 := NewCloseableWaiter()
+	w2 := NewCloseableWaiter()
+	w := WaitAny(w1, w2)
+
+	select {
+	case <-w.Channel():
+		t.Fatal("WaitAny(): closed")
+	case <-time.After(time.Millisecond * 10):
+	}
+	w2.Close()
+	w.Wait()
+	select {
+	case <-w1.Channel():
+		t.Fatal("WaitAny(): source closed")
+	default:
+	}
+
+	// Close the composite early.
+	w3 := NewCloseableWaiter()
+	w = WaitAny(w3)
+	w.Close()
+	w.Wait()
+	select {
+	case <-w3.Channel():
+		t.Fatal("WaitAny(): source closed")
+	default:
+	}
+
+	w = WaitAny()
+	select {
+	case <-w.Channel():
+		t.Fatal("WaitAny(): closed")
+	default:
+	}
+	w.Close()
+	w.Wait()
+}
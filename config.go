@@ -0,0 +1,88 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+	"time"
+)
+
+// RunnerConfig is a snapshot of the effective options of a runner.
+// It is a plain value type that can be logged or marshaled as JSON for debugging.
+type RunnerConfig struct {
+	// Logger is the type name of the runner logger.
+	Logger string `json:"logger"`
+
+	// ShutdownRecoverLogger reports whether the WithShutdownRecoverLogger option is set.
+	ShutdownRecoverLogger bool `json:"shutdown_recover_logger"`
+
+	// ForceExitAfter is the exit time limit set by the WithForceExitAfter option,
+	// zero means that there is no time limit.
+	ForceExitAfter time.Duration `json:"force_exit_after"`
+
+	// ShutdownOrder is the shutdown order mode of the runner, "reverse" by default,
+	// or "phased" if the WithPhases option is set.
+	ShutdownOrder string `json:"shutdown_order"`
+
+	// Phases is the shutdown phases set by the WithPhases option.
+	Phases []string `json:"phases"`
+
+	// DedupeShutdownErrors reports whether the WithDedupeShutdownErrors option is set.
+	DedupeShutdownErrors bool `json:"dedupe_shutdown_errors"`
+
+	// GracePeriod is the shutdown grace period set by the WithGracePeriod option.
+	GracePeriod time.Duration `json:"grace_period"`
+
+	// ExitProgress reports whether the WithExitProgress option is set.
+	ExitProgress bool `json:"exit_progress"`
+
+	// TestMode reports whether the WithTestMode option is set.
+	TestMode bool `json:"test_mode"`
+
+	// HardShutdownDeadline is the deadline set by the WithHardShutdownDeadline option.
+	HardShutdownDeadline time.Duration `json:"hard_shutdown_deadline"`
+
+	// EventHistory is the number of the retained events set by the WithEventHistory option.
+	EventHistory int `json:"event_history"`
+
+	// ErrorSeparator is the separator set by the WithErrorSeparator option.
+	ErrorSeparator string `json:"error_separator"`
+
+	// TaskDecorators is the number of the decorators added by the WithTaskDecorator option.
+	TaskDecorators int `json:"task_decorators"`
+}
+
+// Config method returns the snapshot of the effective options of the current runner.
+func (r *runner) Config() RunnerConfig {
+	c := RunnerConfig{
+		Logger:                fmt.Sprintf("%T", r.logger),
+		ShutdownRecoverLogger: r.logShutdownPanic,
+		ForceExitAfter:        r.forceExitAfter,
+		ShutdownOrder:         "reverse",
+		DedupeShutdownErrors:  r.dedupeShutdownErrors,
+		GracePeriod:           r.gracePeriod,
+		ExitProgress:          r.exitProgress != nil,
+		TestMode:              r.testMode,
+		HardShutdownDeadline:  r.hardShutdownDeadline,
+		EventHistory:          r.historySize,
+		ErrorSeparator:        r.errorSeparator,
+		TaskDecorators:        len(r.taskDecorators),
+	}
+	if len(r.phases) > 0 {
+		c.ShutdownOrder = "phased"
+		c.Phases = append([]string(nil), r.phases...)
+	}
+	return c
+}
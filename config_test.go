@@ -0,0 +1,57 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRunner_Config(t *testing.T) {
+	got := New().Config()
+	want := RunnerConfig{Logger: "runner.stdLogger", ShutdownOrder: "reverse"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Runner.Config(): %+v", got)
+	}
+
+	got = New(
+		WithLogger(new(testLogger)),
+		WithShutdownRecoverLogger(),
+		WithForceExitAfter(5*time.Second, nil),
+		WithPhases("ingress", "storage"),
+		WithDedupeShutdownErrors(),
+		WithGracePeriod(time.Second),
+		WithTaskDecorator(func(t Task) Task { return t }),
+	).Config()
+	want = RunnerConfig{
+		Logger:                "*runner.testLogger",
+		ShutdownRecoverLogger: true,
+		ForceExitAfter:        5 * time.Second,
+		ShutdownOrder:         "phased",
+		Phases:                []string{"ingress", "storage"},
+		DedupeShutdownErrors:  true,
+		GracePeriod:           time.Second,
+		TaskDecorators:        1,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("Runner.Config(): %+v", got)
+	}
+
+	if _, err := json.Marshal(got); err != nil {
+		t.Fatalf("Runner.Config(): %s", err)
+	}
+}
@@ -15,6 +15,9 @@
 package runner
 
 import (
+	"errors"
+	"fmt"
+	"reflect"
 	"strings"
 )
 
@@ -22,24 +25,68 @@ import (
 // errors of the same type within a cycle within an application.
 type Errors struct {
 	errs []error
+	// The index of the cause in the error list plus one, zero if the cause is not set.
+	// The cause is tracked by its index instead of comparing the errors, because the
+	// comparison panics if the dynamic type of the error is not comparable.
+	cause int
+	// The separator of the error messages, the default separator is used if empty.
+	sep string
+	// The occurrences of the error messages added by the AddUnique method.
+	counts map[string]int
 }
 
+// The default separator of the error messages.
+const defaultErrorsSeparator = "; "
+
 // Error method is an implementation of the error interface.
+// If the cause is set, it is marked as the cause, and the other errors are
+// marked as secondary, such as: "cause: X; also: Y; Z".
 func (e *Errors) Error() string {
+	if e.sep == "" {
+		return e.ErrorWith(defaultErrorsSeparator)
+	}
+	return e.ErrorWith(e.sep)
+}
+
+// ErrorWith method is like Error, but the error messages are joined by the given separator.
+func (e *Errors) ErrorWith(sep string) string {
+	if e.cause > 0 {
+		s := "cause: " + e.message(e.errs[e.cause-1])
+		others := make([]string, 0, len(e.errs))
+		for i, j := 0, len(e.errs); i < j; i++ {
+			if i != e.cause-1 {
+				others = append(others, e.message(e.errs[i]))
+			}
+		}
+		if len(others) > 0 {
+			s += sep + "also: " + strings.Join(others, sep)
+		}
+		return s
+	}
 	switch l := len(e.errs); l {
 	case 0:
 		return "<empty errors>"
 	case 1:
-		return e.errs[0].Error()
+		return e.message(e.errs[0])
 	default:
 		s := make([]string, 0, l)
 		for i := 0; i < l; i++ {
-			s = append(s, e.errs[i].Error())
+			s = append(s, e.message(e.errs[i]))
 		}
-		return strings.Join(s, "; ")
+		return strings.Join(s, sep)
 	}
 }
 
+// Returns the message of the given error, with the number of the occurrences if the
+// error is added by the AddUnique method more than once, such as "refused (x5)".
+func (e *Errors) message(err error) string {
+	s := err.Error()
+	if n := e.counts[s]; n > 1 {
+		return fmt.Sprintf("%s (x%d)", s, n)
+	}
+	return s
+}
+
 // Add method adds an error to the current error list.
 // If the given error is nil, it is automatically ignored.
 func (e *Errors) Add(err error) {
@@ -55,6 +102,93 @@ func (e *Errors) Add(err error) {
 	}
 }
 
+// AddUnique method is like Add, but the error whose message is already in the current
+// error list is not added again, instead, the number of its occurrences is counted,
+// and the message is rendered with the number, such as "connection refused (x5)".
+// If the given error is nil, it is automatically ignored.
+func (e *Errors) AddUnique(err error) {
+	if err == nil {
+		return
+	}
+	if v, ok := err.(*Errors); ok {
+		for i := range v.errs {
+			e.AddUnique(v.errs[i])
+		}
+		return
+	}
+
+	s := err.Error()
+	for i := range e.errs {
+		if e.errs[i].Error() == s {
+			if e.counts == nil {
+				e.counts = make(map[string]int)
+			}
+			// The error in the list is the first occurrence.
+			if e.counts[s] == 0 {
+				e.counts[s] = 1
+			}
+			e.counts[s]++
+			return
+		}
+	}
+	e.errs = append(e.errs, err)
+	// The count of the removed error is stale.
+	delete(e.counts, s)
+}
+
+// Returns a copy of the occurrences of the error messages, or nil if there is none.
+func (e *Errors) copyCounts() map[string]int {
+	if len(e.counts) == 0 {
+		return nil
+	}
+	counts := make(map[string]int, len(e.counts))
+	for s, n := range e.counts {
+		counts[s] = n
+	}
+	return counts
+}
+
+// AddContext method adds an error annotated with the given context to the current
+// error list, such as "cache: connection reset". The annotated error wraps the given
+// error, so it can still be matched by the errors.Is and errors.As functions.
+// If the given error is nil, it is automatically ignored.
+func (e *Errors) AddContext(ctx string, err error) {
+	if err != nil {
+		e.Add(fmt.Errorf("%s: %w", ctx, err))
+	}
+}
+
+// SetCause method marks the given error as the primary error of the current error
+// list, the other errors are secondary, such as the cascading errors of a rollback.
+// If the given error is not in the list, it is added to the front of the list.
+// The error whose dynamic type is not comparable is never found in the list.
+// If the given error is nil, the cause is cleared.
+func (e *Errors) SetCause(err error) {
+	if err == nil {
+		e.cause = 0
+		return
+	}
+	if reflect.TypeOf(err).Comparable() {
+		for i, j := 0, len(e.errs); i < j; i++ {
+			if e.errs[i] == err {
+				e.cause = i + 1
+				return
+			}
+		}
+	}
+	e.errs = append([]error{err}, e.errs...)
+	e.cause = 1
+}
+
+// Cause method returns the primary error of the current error list,
+// or nil if the cause is not set.
+func (e *Errors) Cause() error {
+	if e.cause == 0 {
+		return nil
+	}
+	return e.errs[e.cause-1]
+}
+
 // First method returns the first error in the current error list
 // or nil if the list is empty.
 func (e *Errors) First() error {
@@ -78,7 +212,146 @@ func (e *Errors) Len() int {
 	return len(e.errs)
 }
 
+// Returns the current error list as an error. If the list is empty, returns nil,
+// and if there is only one error in the list, returns the error directly.
+func (e *Errors) result() error {
+	if len(e.errs) > 1 {
+		return e
+	}
+	return e.First()
+}
+
 // All method returns all errors in the current error list.
 func (e *Errors) All() []error {
 	return e.errs
 }
+
+// Range method calls the given function for each error in the current error list
+// in order. If the given function returns false, the iteration stops.
+func (e *Errors) Range(fn func(i int, err error) bool) {
+	for i, j := 0, len(e.errs); i < j; i++ {
+		if !fn(i, e.errs[i]) {
+			return
+		}
+	}
+}
+
+// Deduplicate method returns a new error list without the errors whose messages
+// are duplicated, only the first occurrence of each message is kept.
+// The current error list is not modified.
+func (e *Errors) Deduplicate() *Errors {
+	r := &Errors{sep: e.sep, counts: e.copyCounts()}
+	seen := make(map[string]bool, len(e.errs))
+	// The cause is always kept.
+	if cause := e.Cause(); cause != nil {
+		seen[cause.Error()] = true
+		r.errs = append(r.errs, cause)
+		r.cause = 1
+	}
+	for i, j := 0, len(e.errs); i < j; i++ {
+		if s := e.errs[i].Error(); !seen[s] {
+			seen[s] = true
+			r.errs = append(r.errs, e.errs[i])
+		}
+	}
+	return r
+}
+
+// Equal method determines whether the current error list is equal to the given
+// error list. The two lists are compared by the ordered messages of the contained
+// errors, not by the identity, so that wrapped errors with the same message are
+// considered equal. A nil error list is considered equal to an empty one.
+func (e *Errors) Equal(other *Errors) bool {
+	var a, b []error
+	if e != nil {
+		a = e.errs
+	}
+	if other != nil {
+		b = other.errs
+	}
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Error() != b[i].Error() {
+			return false
+		}
+	}
+	return true
+}
+
+// Contains method determines whether any error in the current error list
+// matches the given target error. The matching is done by errors.Is.
+func (e *Errors) Contains(target error) bool {
+	for i, j := 0, e.Len(); i < j; i++ {
+		if errors.Is(e.errs[i], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Is method reports whether any error in the current error list matches the given
+// target error, so the errors.Is function can see through the error list, such as
+// errors.Is(err, ErrExited) where err is the aggregated error.
+func (e *Errors) Is(target error) bool {
+	return e.Contains(target)
+}
+
+// As method finds the first error in the current error list that matches the given
+// target, and if one is found, sets the target to that error value and returns true,
+// so the errors.As function can see through the error list.
+func (e *Errors) As(target interface{}) bool {
+	for i, j := 0, e.Len(); i < j; i++ {
+		if errors.As(e.errs[i], target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Filter method returns a new error list with only the errors for which the given
+// function returns true, the current error list is not modified. The cause is kept
+// if it is kept by the given function.
+func (e *Errors) Filter(keep func(error) bool) *Errors {
+	r := &Errors{sep: e.sep, counts: e.copyCounts()}
+	for i, j := 0, len(e.errs); i < j; i++ {
+		if keep(e.errs[i]) {
+			r.errs = append(r.errs, e.errs[i])
+			if i == e.cause-1 {
+				r.cause = len(r.errs)
+			}
+		}
+	}
+	return r
+}
+
+// Remove method removes the errors that match the given target error from the
+// current error list in place. The matching is done by errors.Is.
+// If the cause is removed, it is cleared.
+func (e *Errors) Remove(target error) {
+	r := e.Filter(func(err error) bool { return !errors.Is(err, target) })
+	e.errs, e.cause = r.errs, r.cause
+}
+
+// Wrap method returns a new error list in which each error is annotated with the
+// given prefix, such as "shutdown: err1; shutdown: err2". The annotated errors wrap
+// the original errors, so they can still be matched by the errors.Is and errors.As
+// functions. The current error list is not modified.
+func (e *Errors) Wrap(prefix string) *Errors {
+	r := &Errors{errs: make([]error, 0, len(e.errs)), sep: e.sep}
+	for i, j := 0, len(e.errs); i < j; i++ {
+		err := fmt.Errorf("%s: %w", prefix, e.errs[i])
+		r.errs = append(r.errs, err)
+		if n := e.counts[e.errs[i].Error()]; n > 0 {
+			if r.counts == nil {
+				r.counts = make(map[string]int)
+			}
+			r.counts[err.Error()] = n
+		}
+		if i == e.cause-1 {
+			r.cause = len(r.errs)
+		}
+	}
+	return r
+}
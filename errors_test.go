@@ -16,6 +16,8 @@ package runner
 
 import (
 	"errors"
+	"fmt"
+	"strings"
 	"testing"
 )
 
@@ -73,3 +75,330 @@ func TestErrors(t *testing.T) {
 		t.Fatalf("Errors.All(): %v", v)
 	}
 }
+
+func TestErrors_Equal(t *testing.T) {
+	err := errors.New("test1")
+
+	errs1 := new(Errors)
+	errs2 := new(Errors)
+	if !errs1.Equal(errs2) {
+		t.Fatal("Errors.Equal(): false")
+	}
+
+	errs1.Add(err)
+	errs1.Add(errors.New("test2"))
+	if errs1.Equal(errs2) {
+		t.Fatal("Errors.Equal(): true")
+	}
+
+	errs2.Add(fmt.Errorf("%w", err))
+	errs2.Add(errors.New("test2"))
+	if !errs1.Equal(errs2) {
+		t.Fatal("Errors.Equal(): false")
+	}
+
+	errs3 := new(Errors)
+	errs3.Add(errors.New("test2"))
+	errs3.Add(err)
+	if errs1.Equal(errs3) {
+		t.Fatal("Errors.Equal(): true")
+	}
+
+	var nilErrs *Errors
+	if !nilErrs.Equal(nil) || !nilErrs.Equal(new(Errors)) || !new(Errors).Equal(nil) {
+		t.Fatal("Errors.Equal(): false")
+	}
+	if nilErrs.Equal(errs1) || errs1.Equal(nil) {
+		t.Fatal("Errors.Equal(): true")
+	}
+}
+
+func TestErrors_Contains(t *testing.T) {
+	err := errors.New("test")
+
+	errs := new(Errors)
+	if errs.Contains(err) {
+		t.Fatal("Errors.Contains(): true")
+	}
+
+	errs.Add(errors.New("test"))
+	if errs.Contains(err) {
+		t.Fatal("Errors.Contains(): true")
+	}
+
+	errs.Add(fmt.Errorf("wrapped: %w", err))
+	if !errs.Contains(err) {
+		t.Fatal("Errors.Contains(): false")
+	}
+}
+
+func TestErrors_IsAs(t *testing.T) {
+	errs := new(Errors)
+	errs.Add(errors.New("test"))
+	errs.Add(fmt.Errorf("wrapped: %w", ErrExited))
+	errs.Add(SafeCall(func() error { panic("test panic") }))
+
+	var err error = errs
+	if !errors.Is(err, ErrExited) {
+		t.Fatal("errors.Is(): false")
+	}
+	if errors.Is(err, ErrNotExited) {
+		t.Fatal("errors.Is(): true")
+	}
+	if s := err.Error(); s != "test; wrapped: runner: exited; test panic" {
+		t.Fatalf("Errors.Error(): %s", s)
+	}
+
+	var pe *PanicError
+	if !errors.As(fmt.Errorf("outer: %w", err), &pe) || pe.Error() != "test panic" {
+		t.Fatalf("errors.As(): %v", pe)
+	}
+	if errors.As(new(Errors), &pe) {
+		t.Fatal("errors.As(): true")
+	}
+}
+
+func TestErrors_Filter(t *testing.T) {
+	err1 := errors.New("err1")
+	err2 := errors.New("err2")
+	errs := new(Errors)
+	errs.Add(err1)
+	errs.Add(err2)
+	errs.Add(fmt.Errorf("wrapped: %w", err1))
+	errs.SetCause(err2)
+
+	r := errs.Filter(func(err error) bool { return err != err1 })
+	if r.Len() != 2 || r.Cause() != err2 || errs.Len() != 3 {
+		t.Fatalf("Errors.Filter(): %s", r)
+	}
+	r = errs.Filter(func(error) bool { return false })
+	if r.Len() != 0 || r.Cause() != nil || r.Error() != "<empty errors>" {
+		t.Fatalf("Errors.Filter(): %s", r)
+	}
+
+	errs.Remove(err1)
+	if errs.Len() != 1 || errs.Error() != "cause: err2" {
+		t.Fatalf("Errors.Remove(): %s", errs)
+	}
+	errs.Remove(err2)
+	if errs.Len() != 0 || errs.Cause() != nil || errs.Error() != "<empty errors>" {
+		t.Fatalf("Errors.Remove(): %s", errs)
+	}
+}
+
+func TestErrors_Wrap(t *testing.T) {
+	errs := new(Errors)
+	errs.Add(errors.New("err1"))
+	errs.Add(ErrExited)
+
+	r := errs.Wrap("shutdown")
+	if s := r.Error(); s != "shutdown: err1; shutdown: runner: exited" {
+		t.Fatalf("Errors.Wrap(): %s", s)
+	}
+	if !errors.Is(r, ErrExited) {
+		t.Fatal("Errors.Wrap(): errors.Is() false")
+	}
+	if s := errs.Error(); s != "err1; runner: exited" {
+		t.Fatalf("Errors.Wrap(): %s", s)
+	}
+
+	errs.SetCause(ErrExited)
+	if s := errs.Wrap("app").Wrap("main").Error(); s != "cause: main: app: runner: exited; also: main: app: err1" {
+		t.Fatalf("Errors.Wrap(): %s", s)
+	}
+}
+
+func TestErrors_AddUnique(t *testing.T) {
+	errs := new(Errors)
+	errs.AddUnique(nil)
+	for i := 0; i < 5; i++ {
+		errs.AddUnique(errors.New("connection refused"))
+	}
+	errs.AddUnique(errors.New("timeout"))
+	if errs.Len() != 2 || errs.Error() != "connection refused (x5); timeout" {
+		t.Fatalf("Errors.AddUnique(): %s", errs)
+	}
+
+	// The error added by the Add method is counted as the first occurrence.
+	errs.Add(errors.New("closed"))
+	other := new(Errors)
+	other.Add(errors.New("closed"))
+	other.Add(errors.New("timeout"))
+	errs.AddUnique(other)
+	if errs.Len() != 3 || errs.Error() != "connection refused (x5); timeout (x2); closed (x2)" {
+		t.Fatalf("Errors.AddUnique(): %s", errs)
+	}
+	if s := errs.Filter(func(error) bool { return true }).Error(); s != errs.Error() {
+		t.Fatalf("Errors.Filter(): %s", s)
+	}
+
+	if s := errs.Wrap("app").First().Error(); s != "app: connection refused" ||
+		!strings.HasPrefix(errs.Wrap("app").Error(), "app: connection refused (x5)") {
+		t.Fatalf("Errors.Wrap(): %s", s)
+	}
+
+	// The plain Add method is unchanged.
+	errs.Add(errors.New("timeout"))
+	if errs.Len() != 4 {
+		t.Fatalf("Errors.Add(): %s", errs)
+	}
+
+	// The removed error is counted again from the first occurrence.
+	errs.Remove(errs.First())
+	errs.AddUnique(errors.New("connection refused"))
+	if s := errs.Last().Error(); s != "connection refused" || strings.Contains(errs.Error(), "(x5)") {
+		t.Fatalf("Errors.AddUnique(): %s", errs)
+	}
+}
+
+func TestErrors_Range(t *testing.T) {
+	errs := new(Errors)
+	errs.Range(func(int, error) bool {
+		t.Fatal("Errors.Range(): called")
+		return true
+	})
+
+	errs.Add(errors.New("test1"))
+	errs.Add(errors.New("test2"))
+	errs.Add(errors.New("test3"))
+
+	var ss []string
+	errs.Range(func(i int, err error) bool {
+		ss = append(ss, fmt.Sprintf("%d:%s", i, err))
+		return true
+	})
+	if got := strings.Join(ss, ","); got != "0:test1,1:test2,2:test3" {
+		t.Fatalf("Errors.Range(): %s", got)
+	}
+
+	ss = nil
+	errs.Range(func(i int, err error) bool {
+		ss = append(ss, err.Error())
+		return i < 1
+	})
+	if got := strings.Join(ss, ","); got != "test1,test2" {
+		t.Fatalf("Errors.Range(): %s", got)
+	}
+}
+
+func TestErrors_Deduplicate(t *testing.T) {
+	err := errors.New("test1")
+	errs := new(Errors)
+	errs.Add(err)
+	errs.Add(errors.New("test2"))
+	errs.Add(err)
+	errs.Add(errors.New("test1"))
+
+	got := errs.Deduplicate()
+	if s := got.Error(); s != "test1; test2" {
+		t.Fatalf("Errors.Deduplicate(): %s", s)
+	}
+	if got.First() != err {
+		t.Fatal("Errors.Deduplicate(): not first occurrence")
+	}
+	if n := errs.Len(); n != 4 {
+		t.Fatalf("Errors.Deduplicate(): %d", n)
+	}
+}
+
+func TestErrors_Cause(t *testing.T) {
+	errs := new(Errors)
+	if errs.Cause() != nil {
+		t.Fatalf("Errors.Cause(): %v", errs.Cause())
+	}
+
+	cause := errors.New("execute")
+	errs.Add(errors.New("shutdown1"))
+	errs.Add(errors.New("shutdown2"))
+	errs.SetCause(cause)
+	if errs.Cause() != cause || errs.First() != cause || errs.Len() != 3 {
+		t.Fatalf("Errors.SetCause(): %v", errs.Cause())
+	}
+	if s := errs.Error(); s != "cause: execute; also: shutdown1; shutdown2" {
+		t.Fatalf("Errors.Error(): %s", s)
+	}
+
+	// The cause already in the list is not added again.
+	errs.SetCause(errs.Last())
+	if errs.Len() != 3 {
+		t.Fatalf("Errors.SetCause(): %d", errs.Len())
+	}
+	if s := errs.Error(); s != "cause: shutdown2; also: execute; shutdown1" {
+		t.Fatalf("Errors.Error(): %s", s)
+	}
+	if s := errs.Deduplicate().Error(); s != "cause: shutdown2; also: execute; shutdown1" {
+		t.Fatalf("Errors.Deduplicate(): %s", s)
+	}
+
+	errs = new(Errors)
+	errs.SetCause(cause)
+	if s := errs.Error(); s != "cause: execute" {
+		t.Fatalf("Errors.Error(): %s", s)
+	}
+	errs.SetCause(nil)
+	if s := errs.Error(); s != "execute" {
+		t.Fatalf("Errors.Error(): %s", s)
+	}
+}
+
+type testUncomparableError struct {
+	messages []string
+}
+
+func (e testUncomparableError) Error() string {
+	return strings.Join(e.messages, ",")
+}
+
+func TestErrors_Cause_Uncomparable(t *testing.T) {
+	cause := testUncomparableError{messages: []string{"execute"}}
+	errs := new(Errors)
+	errs.Add(testUncomparableError{messages: []string{"shutdown"}})
+	errs.SetCause(cause)
+	if s := errs.Error(); s != "cause: execute; also: shutdown" {
+		t.Fatalf("Errors.Error(): %s", s)
+	}
+	if s := errs.Wrap("app").Error(); s != "cause: app: execute; also: app: shutdown" {
+		t.Fatalf("Errors.Wrap(): %s", s)
+	}
+	if s := errs.Filter(func(error) bool { return true }).Error(); s != "cause: execute; also: shutdown" {
+		t.Fatalf("Errors.Filter(): %s", s)
+	}
+	if s := errs.Deduplicate().Error(); s != "cause: execute; also: shutdown" {
+		t.Fatalf("Errors.Deduplicate(): %s", s)
+	}
+	errs.Remove(ErrExited)
+	if s := errs.Error(); s != "cause: execute; also: shutdown" {
+		t.Fatalf("Errors.Remove(): %s", s)
+	}
+}
+
+func TestErrors_AddContext(t *testing.T) {
+	target := errors.New("connection reset")
+	errs := new(Errors)
+	errs.AddContext("cache", target)
+	errs.AddContext("db", nil)
+	errs.AddContext("db", errors.New("timeout"))
+
+	if s := errs.Error(); s != "cache: connection reset; db: timeout" {
+		t.Fatalf("Errors.AddContext(): %s", s)
+	}
+	if !errors.Is(errs.First(), target) || !errs.Contains(target) {
+		t.Fatal("Errors.AddContext(): not wrapped")
+	}
+}
+
+func TestErrors_ErrorWith(t *testing.T) {
+	errs := new(Errors)
+	errs.Add(errors.New("test1"))
+	errs.Add(errors.New("test2"))
+	if s := errs.ErrorWith("\n"); s != "test1\ntest2" {
+		t.Fatalf("Errors.ErrorWith(): %q", s)
+	}
+	if s := errs.Error(); s != "test1; test2" {
+		t.Fatalf("Errors.Error(): %q", s)
+	}
+	errs.SetCause(errs.Last())
+	if s := errs.ErrorWith("\n"); s != "cause: test2\nalso: test1" {
+		t.Fatalf("Errors.ErrorWith(): %q", s)
+	}
+}
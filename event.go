@@ -0,0 +1,247 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"time"
+)
+
+// The buffer size of the runner lifecycle event stream.
+const eventBufferSize = 128
+
+// EventKind defines the kind of the runner lifecycle event.
+type EventKind int
+
+// These are the kinds of the runner lifecycle events.
+const (
+	// EventTaskRun is emitted when a task is executed successfully.
+	EventTaskRun EventKind = iota + 1
+	// EventTaskRunError is emitted when a task fails to execute.
+	EventTaskRunError
+	// EventExitStarted is emitted when the runner starts to exit.
+	EventExitStarted
+	// EventTaskShutdown is emitted when a task is shut down successfully.
+	EventTaskShutdown
+	// EventTaskShutdownError is emitted when a task fails to shut down.
+	EventTaskShutdownError
+	// EventExitFinished is emitted when the runner has exited.
+	EventExitFinished
+	// EventTaskShutdownStarted is emitted when a task starts to shut down.
+	EventTaskShutdownStarted
+)
+
+// String returns the name of the current event kind.
+func (k EventKind) String() string {
+	switch k {
+	case EventTaskRun:
+		return "TaskRun"
+	case EventTaskRunError:
+		return "TaskRunError"
+	case EventExitStarted:
+		return "ExitStarted"
+	case EventTaskShutdown:
+		return "TaskShutdown"
+	case EventTaskShutdownError:
+		return "TaskShutdownError"
+	case EventExitFinished:
+		return "ExitFinished"
+	case EventTaskShutdownStarted:
+		return "TaskShutdownStarted"
+	}
+	return "Unknown"
+}
+
+// Event defines the runner lifecycle event.
+type Event struct {
+	// Kind is the kind of the current event.
+	Kind EventKind
+	// Task is the task related to the current event, nil for runner events.
+	Task Task
+	// Err is the error related to the current event, if any.
+	Err error
+	// Time is the time when the current event is emitted.
+	Time time.Time
+}
+
+// TaskName method returns the name of the task related to the current event, which
+// is the name of the NamedTask or the type name of the task, or an empty string for
+// the runner events.
+func (e Event) TaskName() string {
+	if e.Task == nil {
+		return ""
+	}
+	return taskName(e.Task)
+}
+
+// Subscribe method registers an observer of the lifecycle events of the current
+// runner. The observers are called in the order of registration for each event,
+// never concurrently, so they receive the events in order, and they should return
+// quickly. The panic of an observer is only logged. The observers are called after
+// the lock of the runner is released, so they can call the methods of the runner,
+// except the exit methods, which wait for the exit in progress.
+func (r *runner) Subscribe(observer func(Event)) {
+	r.eventMutex.Lock()
+	r.observers = append(r.observers, observer)
+	r.eventMutex.Unlock()
+}
+
+// Call the given observers with the given event, the panic is only logged.
+func (r *runner) notify(observers []func(Event), e Event) {
+	for _, observer := range observers {
+		if err := SafeCall(func() error {
+			observer(e)
+			return nil
+		}); err != nil {
+			r.logger.Printf("runner: event observer panic: %s", err)
+		}
+	}
+}
+
+// Events method returns the lifecycle event stream of the current runner.
+// The stream is a buffered channel, when it is full, the oldest event is
+// dropped, so consumers must keep draining it. Events are only recorded
+// after this method is called for the first time.
+func (r *runner) Events() <-chan Event {
+	r.eventMutex.Lock()
+	defer r.eventMutex.Unlock()
+
+	if r.events == nil {
+		r.events = make(chan Event, eventBufferSize)
+		if r.eventStopped {
+			close(r.events)
+		}
+	}
+	return r.events
+}
+
+// EventsStop method closes the lifecycle event stream of the current runner.
+// It is usually called after the runner exits. This method is idempotent.
+func (r *runner) EventsStop() {
+	r.eventMutex.Lock()
+	defer r.eventMutex.Unlock()
+
+	if !r.eventStopped {
+		r.eventStopped = true
+		if r.events != nil {
+			close(r.events)
+		}
+	}
+}
+
+// Emit a lifecycle event and notify the observers, the mutex of the runner must not
+// be held.
+func (r *runner) emit(kind EventKind, t Task, err error) {
+	r.emitLocked(kind, t, err)
+	r.flushEvents()
+}
+
+// Emit a lifecycle event to the event stream without blocking, and queue it for the
+// observers. If the stream is full, the oldest event will be dropped. It is called
+// while the mutex of the runner is held, so the caller must call the flushEvents
+// method after the mutex is released.
+func (r *runner) emitLocked(kind EventKind, t Task, err error) {
+	r.eventMutex.Lock()
+	defer r.eventMutex.Unlock()
+
+	recording := r.events != nil && !r.eventStopped
+	if !recording && r.historySize <= 0 && len(r.observers) == 0 {
+		return
+	}
+	e := Event{Kind: kind, Err: err, Time: r.now()}
+	if t != nil {
+		e.Task = exposedTask(t)
+	}
+	r.record(e)
+	if recording {
+		r.send(e)
+	}
+	if len(r.observers) > 0 {
+		r.queued = append(r.queued, e)
+	}
+}
+
+// Notify the observers of the queued events in order. If another coroutine is
+// notifying the observers, it also notifies the events queued by the current
+// coroutine, so the observers are never called concurrently.
+func (r *runner) flushEvents() {
+	r.eventMutex.Lock()
+	if r.notifying {
+		r.eventMutex.Unlock()
+		return
+	}
+	r.notifying = true
+	for len(r.queued) > 0 {
+		events, observers := r.queued, r.observers
+		r.queued = nil
+		// The observers are called without the lock, so they can read the history.
+		r.eventMutex.Unlock()
+		for _, e := range events {
+			r.notify(observers, e)
+		}
+		r.eventMutex.Lock()
+	}
+	r.notifying = false
+	r.eventMutex.Unlock()
+}
+
+// Send the given event to the event stream, if the stream is full, the oldest event
+// will be dropped. The event mutex must be held.
+func (r *runner) send(e Event) {
+	for {
+		select {
+		case r.events <- e:
+			return
+		default:
+			// Drop the oldest event and try again.
+			select {
+			case <-r.events:
+			default:
+			}
+		}
+	}
+}
+
+// History method returns the last lifecycle events retained by the current runner,
+// from the oldest to the newest. The number of the retained events is set by the
+// WithEventHistory option, and nil is returned if the option is not set.
+func (r *runner) History() []Event {
+	r.eventMutex.Lock()
+	defer r.eventMutex.Unlock()
+
+	if r.historySize <= 0 {
+		return nil
+	}
+	events := make([]Event, 0, len(r.history))
+	// The history is full, the oldest event is at the next write position.
+	if len(r.history) == r.historySize {
+		events = append(events, r.history[r.historyNext:]...)
+		return append(events, r.history[:r.historyNext]...)
+	}
+	return append(events, r.history...)
+}
+
+// Record the given event in the event history ring buffer.
+// The event mutex must be held.
+func (r *runner) record(e Event) {
+	if r.historySize <= 0 {
+		return
+	}
+	if len(r.history) < r.historySize {
+		r.history = append(r.history, e)
+		return
+	}
+	r.history[r.historyNext] = e
+	r.historyNext = (r.historyNext + 1) % r.historySize
+}
@@ -0,0 +1,209 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestEventKind_String(t *testing.T) {
+	kinds := map[EventKind]string{
+		EventTaskRun:             "TaskRun",
+		EventTaskRunError:        "TaskRunError",
+		EventExitStarted:         "ExitStarted",
+		EventTaskShutdown:        "TaskShutdown",
+		EventTaskShutdownError:   "TaskShutdownError",
+		EventExitFinished:        "ExitFinished",
+		EventTaskShutdownStarted: "TaskShutdownStarted",
+		EventKind(0):             "Unknown",
+	}
+	for k, want := range kinds {
+		if got := k.String(); got != want {
+			t.Fatalf("EventKind.String(): %s", got)
+		}
+	}
+}
+
+func TestRunner_Events(t *testing.T) {
+	r := New()
+	events := r.Events()
+
+	r.MustRun(NewTaskFromFunc(nil))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test") }))
+	if err := r.Run(NewTaskFromFunc(func() error { return errors.New("test") })); err == nil {
+		t.Fatal("Runner.Run(): nil")
+	}
+	if err := r.Exit(); err == nil {
+		t.Fatal("Runner.Exit(): nil")
+	}
+	r.EventsStop()
+	r.EventsStop()
+
+	var kinds []string
+	for e := range events {
+		if e.Time.IsZero() {
+			t.Fatalf("Runner.Events(): %s zero time", e.Kind)
+		}
+		kinds = append(kinds, e.Kind.String())
+	}
+	want := "TaskRun-TaskRun-TaskRunError-ExitStarted-TaskShutdownStarted-TaskShutdownError-" +
+		"TaskShutdownStarted-TaskShutdown-ExitFinished"
+	if got := strings.Join(kinds, "-"); got != want {
+		t.Fatalf("Runner.Events(): %s", got)
+	}
+}
+
+func TestRunner_EventsDropOldest(t *testing.T) {
+	r := New()
+	events := r.Events()
+
+	for i := 0; i < eventBufferSize+10; i++ {
+		r.MustRun(NewTaskFromFunc(nil))
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	r.EventsStop()
+
+	var n int
+	var last Event
+	for e := range events {
+		n++
+		last = e
+	}
+	if n != eventBufferSize {
+		t.Fatalf("Runner.Events(): %d", n)
+	}
+	if last.Kind != EventExitFinished {
+		t.Fatalf("Runner.Events(): %s", last.Kind)
+	}
+}
+
+func TestRunner_EventsStop(t *testing.T) {
+	r := New()
+	r.EventsStop()
+
+	if _, ok := <-r.Events(); ok {
+		t.Fatal("Runner.Events(): not closed")
+	}
+	// The stopped stream does not block the runner.
+	r.MustRun(NewTaskFromFunc(nil))
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+}
+
+func TestRunner_History(t *testing.T) {
+	if h := New().History(); h != nil {
+		t.Fatalf("Runner.History(): %v", h)
+	}
+
+	r := New(WithEventHistory(3))
+	if h := r.History(); len(h) != 0 {
+		t.Fatalf("Runner.History(): %v", h)
+	}
+	r.MustRun(NewNamedTaskFromFunc("db", nil, nil))
+	r.MustRun(NewNamedTaskFromFunc("cache", nil, nil))
+	if h := r.History(); len(h) != 2 || h[0].Kind != EventTaskRun || taskName(h[1].Task) != "cache" {
+		t.Fatalf("Runner.History(): %v", h)
+	}
+
+	// The history wraps around after the exit.
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	var kinds []string
+	for _, e := range r.History() {
+		kinds = append(kinds, e.Kind.String()+":"+func() string {
+			if e.Task == nil {
+				return ""
+			}
+			return taskName(e.Task)
+		}())
+	}
+	if got := strings.Join(kinds, ", "); got != "TaskShutdownStarted:db, TaskShutdown:db, ExitFinished:" {
+		t.Fatalf("Runner.History(): %s", got)
+	}
+
+	// The history does not depend on the event stream.
+	r.EventsStop()
+	if h := r.History(); len(h) != 3 {
+		t.Fatalf("Runner.History(): %v", h)
+	}
+}
+
+func TestRunner_Subscribe(t *testing.T) {
+	logger := new(testLogger)
+	r := New(WithLogger(logger), WithEventHistory(1))
+
+	var got []string
+	r.Subscribe(func(e Event) {
+		s := e.Kind.String()
+		if name := e.TaskName(); name != "" {
+			s += ":" + name
+		}
+		if e.Err != nil {
+			s += ":" + e.Err.Error()
+		}
+		got = append(got, s)
+		// The observer can read the history.
+		if h := r.History(); len(h) != 1 || h[0].Kind != e.Kind {
+			t.Errorf("Runner.History(): %v", h)
+		}
+	})
+	r.Subscribe(func(Event) { panic("test panic") })
+
+	r.MustRun(NewNamedTaskFromFunc("db", nil, func() error { return errors.New("test") }))
+	if err := r.Exit(); err == nil {
+		t.Fatal("Runner.Exit(): nil")
+	}
+
+	want := "TaskRun:db, ExitStarted, TaskShutdownStarted:db, TaskShutdownError:db:test, ExitFinished:db: test"
+	if s := strings.Join(got, ", "); s != want {
+		t.Fatalf("Runner.Subscribe(): %s", s)
+	}
+	if n := len(logger.Messages()); n != 5 {
+		t.Fatalf("Runner.Subscribe(): %d panics logged", n)
+	}
+}
+
+func TestRunner_Subscribe_CallRunner(t *testing.T) {
+	r := New()
+
+	var tasks []int
+	var errs []error
+	r.Subscribe(func(e Event) {
+		// The observer is called without the lock of the runner.
+		tasks = append(tasks, len(r.Tasks()))
+		if e.Kind == EventExitStarted {
+			errs = append(errs, r.Run(NewTaskFromFunc(nil)))
+		}
+	})
+	r.OnBeforeExit(func() { tasks = append(tasks, len(r.Tasks())) })
+
+	r.MustRun(NewTaskFromFunc(nil))
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if fmt.Sprint(tasks) != "[1 1 1 0 0 0]" {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+	if len(errs) != 1 || errs[0] != ErrExited {
+		t.Fatalf("Runner.Run(): %v", errs)
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"syscall"
+	"time"
+)
+
+// The interval of checking whether the FD waiter is closed while polling.
+const fdWaiterPollInterval = 100 * time.Millisecond
+
+// NewFDWaiter creates and returns a waiter that is closed when the given file
+// descriptor becomes readable (including the end of file and the hang up), such as
+// a named pipe or a socket used as the stop signal by a supervisor. The descriptor is
+// polled by epoll in a separate coroutine, so regular files are not supported. Closing
+// the returned waiter explicitly stops the polling coroutine within a short interval,
+// callers must close it when it is no longer needed. The descriptor is never closed
+// or read by the waiter.
+// This function is supported on Linux (epoll), macOS and the BSDs (kqueue), on other
+// platforms it always returns the ErrFDWaiterUnsupported error, see the NewPolledWaiter
+// function for the portable polling of a readiness condition.
+func NewFDWaiter(fd uintptr) (CloseableWaiter, error) {
+	epfd, err := syscall.EpollCreate1(syscall.EPOLL_CLOEXEC)
+	if err != nil {
+		return nil, err
+	}
+	event := syscall.EpollEvent{Events: syscall.EPOLLIN, Fd: int32(fd)}
+	if err = syscall.EpollCtl(epfd, syscall.EPOLL_CTL_ADD, int(fd), &event); err != nil {
+		_ = syscall.Close(epfd)
+		return nil, err
+	}
+
+	w := newCloseableWaiter()
+	go pollFDWaiter(w, epfd)
+	return w, nil
+}
+
+// Poll the given epoll instance until the descriptor becomes readable or the given
+// waiter is closed, the epoll instance is closed before returning.
+func pollFDWaiter(w *closeableWaiter, epfd int) {
+	defer func() { _ = syscall.Close(epfd) }()
+
+	events := make([]syscall.EpollEvent, 1)
+	timeout := int(fdWaiterPollInterval.Milliseconds())
+	for {
+		select {
+		case <-w.Channel():
+			return
+		default:
+		}
+		n, err := syscall.EpollWait(epfd, events, timeout)
+		if err == syscall.EINTR {
+			continue
+		}
+		// The waiter is closed if the descriptor is readable or can not be polled,
+		// otherwise the caller may wait forever.
+		if n > 0 || err != nil {
+			w.Close()
+			return
+		}
+	}
+}
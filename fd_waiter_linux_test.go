@@ -0,0 +1,32 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"os"
+	"testing"
+)
+
+func TestNewFDWaiter_RegularFile(t *testing.T) {
+	// The regular file can not be polled by epoll.
+	f, err := os.Open("fd_waiter.go")
+	if err != nil {
+		t.Fatalf("os.Open(): %s", err)
+	}
+	defer f.Close()
+	if _, err := NewFDWaiter(f.Fd()); err == nil {
+		t.Fatal("NewFDWaiter(): nil error")
+	}
+}
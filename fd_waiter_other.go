@@ -0,0 +1,29 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !linux && !darwin && !dragonfly && !freebsd && !netbsd && !openbsd
+// +build !linux,!darwin,!dragonfly,!freebsd,!netbsd,!openbsd
+
+package runner
+
+// NewFDWaiter creates and returns a waiter that is closed when the given file
+// descriptor becomes readable. This function is supported on Linux, macOS and the
+// BSDs, on the current platform it always returns the ErrFDWaiterUnsupported error.
+// The standard library can not poll the readiness of a descriptor without reading
+// it on the current platform, so there is no polling fallback here. The callers that
+// can check the readiness in their own way, such as checking the existence of a file,
+// can use the NewPolledWaiter function instead.
+func NewFDWaiter(fd uintptr) (CloseableWaiter, error) {
+	return nil, ErrFDWaiterUnsupported
+}
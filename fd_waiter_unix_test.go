@@ -0,0 +1,76 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build darwin || dragonfly || freebsd || linux || netbsd || openbsd
+// +build darwin dragonfly freebsd linux netbsd openbsd
+
+package runner
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestNewFDWaiter(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe(): %s", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	waiter, err := NewFDWaiter(r.Fd())
+	if err != nil {
+		t.Fatalf("NewFDWaiter(): %s", err)
+	}
+	select {
+	case <-waiter.Channel():
+		t.Fatal("NewFDWaiter(): closed")
+	case <-time.After(time.Millisecond * 50):
+	}
+	if _, err := w.Write([]byte("stop")); err != nil {
+		t.Fatalf("File.Write(): %s", err)
+	}
+	select {
+	case <-waiter.Channel():
+	case <-time.After(time.Second * 5):
+		t.Fatal("NewFDWaiter(): not closed")
+	}
+}
+
+func TestNewFDWaiter_Close(t *testing.T) {
+	base := runtime.NumGoroutine()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatalf("os.Pipe(): %s", err)
+	}
+	defer r.Close()
+	defer w.Close()
+
+	waiter, err := NewFDWaiter(r.Fd())
+	if err != nil {
+		t.Fatalf("NewFDWaiter(): %s", err)
+	}
+	waiter.Close()
+	deadline := time.Now().Add(time.Second * 5)
+	for runtime.NumGoroutine() > base {
+		if time.Now().After(deadline) {
+			t.Fatalf("NewFDWaiter(): %d > %d", runtime.NumGoroutine(), base)
+		}
+		time.Sleep(time.Millisecond * 10)
+	}
+
+}
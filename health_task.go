@@ -0,0 +1,112 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+// NewHealthMonitorTask creates a task that monitors the health of the application.
+// The Execute method starts a coroutine that calls the given check function every
+// interval, a returned error or a recovered panic counts as a failure. After the given
+// number of consecutive failures, the monitor calls onUnhealthy with the last error
+// (typically to exit the runner) and stops. The Shutdown method cancels the context
+// of the running check and waits for the monitor to stop.
+// If the given threshold is less than 1, it is treated as 1. The given interval must
+// be positive, otherwise this function panics.
+func NewHealthMonitorTask(check func(ctx context.Context) error, interval time.Duration,
+	failureThreshold int, onUnhealthy func(error)) Task {
+	if interval <= 0 {
+		panic("NewHealthMonitorTask(): interval must be a positive duration")
+	}
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+	return &healthMonitorTask{
+		check:       check,
+		interval:    interval,
+		threshold:   failureThreshold,
+		onUnhealthy: onUnhealthy,
+	}
+}
+
+// The healthMonitorTask type is the task that monitors the health of the application.
+type healthMonitorTask struct {
+	check       func(ctx context.Context) error
+	interval    time.Duration
+	threshold   int
+	onUnhealthy func(error)
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+// Execute method starts the monitor coroutine.
+func (t *healthMonitorTask) Execute() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.done = make(chan struct{})
+	var err error
+	goManaged(func() { err = t.watch(ctx) }, func() { t.stopped(err) })
+	return nil
+}
+
+// Shutdown method stops the monitor coroutine and waits for it to exit.
+func (t *healthMonitorTask) Shutdown() error {
+	if t.cancel != nil {
+		t.cancel()
+		<-t.done
+	}
+	return nil
+}
+
+// Called with the error of the monitor after it stops, the onUnhealthy function is
+// called after the monitor is stopped, so it can shut down the current task (exit
+// the runner).
+func (t *healthMonitorTask) stopped(err error) {
+	close(t.done)
+	if err != nil && t.onUnhealthy != nil {
+		t.onUnhealthy(err)
+	}
+}
+
+// Check the health every interval until the given context is canceled or the
+// failure threshold is reached, in which case the last error is returned.
+func (t *healthMonitorTask) watch(ctx context.Context) error {
+	ticker := time.NewTicker(t.interval)
+	defer ticker.Stop()
+
+	var failures int
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		err := SafeCall(func() error { return t.check(ctx) })
+		if err == nil {
+			failures = 0
+			continue
+		}
+		// The failure caused by the shutdown is not counted.
+		if ctx.Err() != nil {
+			return nil
+		}
+		if failures++; failures >= t.threshold {
+			return err
+		}
+	}
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewHealthMonitorTask(t *testing.T) {
+	var calls int32
+	unhealthy := make(chan error, 1)
+	task := NewHealthMonitorTask(func(context.Context) error {
+		switch atomic.AddInt32(&calls, 1) {
+		case 1:
+			return errors.New("test1")
+		case 2:
+			return nil
+		case 3:
+			return errors.New("test3")
+		default:
+			panic("test4")
+		}
+	}, time.Millisecond, 2, func(err error) { unhealthy <- err })
+
+	if err := task.Execute(); err != nil {
+		t.Fatalf("NewHealthMonitorTask(): Execute() %s", err)
+	}
+	select {
+	case err := <-unhealthy:
+		if !IsPanicError(err) || err.Error() != "test4" {
+			t.Fatalf("NewHealthMonitorTask(): %v", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("NewHealthMonitorTask(): not unhealthy")
+	}
+	if n := atomic.LoadInt32(&calls); n != 4 {
+		t.Fatalf("NewHealthMonitorTask(): calls %d", n)
+	}
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("NewHealthMonitorTask(): Shutdown() %s", err)
+	}
+}
+
+func TestNewHealthMonitorTask_Shutdown(t *testing.T) {
+	started := make(chan struct{}, 1)
+	task := NewHealthMonitorTask(func(ctx context.Context) error {
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		<-ctx.Done()
+		return ctx.Err()
+	}, time.Millisecond, 1, func(err error) {
+		t.Errorf("NewHealthMonitorTask(): unhealthy %s", err)
+	})
+
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("NewHealthMonitorTask(): Shutdown() %s", err)
+	}
+	if err := task.Execute(); err != nil {
+		t.Fatalf("NewHealthMonitorTask(): Execute() %s", err)
+	}
+	<-started
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("NewHealthMonitorTask(): Shutdown() %s", err)
+	}
+}
+
+func TestNewHealthMonitorTask_Exit(t *testing.T) {
+	r := New()
+	r.MustRun(NewHealthMonitorTask(func(context.Context) error {
+		return errors.New("test")
+	}, time.Millisecond, 3, func(error) {
+		if err := r.Exit(); err != nil {
+			t.Errorf("Runner.Exit(): %s", err)
+		}
+	}))
+	select {
+	case <-r.Done():
+	case <-time.After(time.Second * 5):
+		t.Fatal("NewHealthMonitorTask(): runner not exited")
+	}
+}
+
+func TestNewHealthMonitorTask_InvalidInterval(t *testing.T) {
+	defer func() {
+		if v := recover(); v == nil {
+			t.Fatal("NewHealthMonitorTask(): no panic")
+		}
+	}()
+	NewHealthMonitorTask(func(context.Context) error { return nil }, 0, 1, nil)
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package runner
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// Lazy is a concurrency-safe lazily initialized value.
+// It is usually used for the resources exposed by a task, the Execute method of the
+// task calls the Get method to force the initialization, and the dependents access
+// the resource after the task is executed.
+// The zero value of Lazy is ready to use, and is initialized to the zero value of T.
+type Lazy[T any] struct {
+	init    func() (T, error)
+	started int32
+	once    sync.Once
+	done    chan struct{}
+	value   T
+	err     error
+}
+
+// NewLazy creates and returns a new Lazy instance with the given initialization function.
+func NewLazy[T any](init func() (T, error)) *Lazy[T] {
+	return &Lazy[T]{init: init}
+}
+
+// Get runs the initialization function once and returns its result.
+// Subsequent calls return the cached value and error. If the initialization
+// function panics, a PanicError is returned.
+func (l *Lazy[T]) Get() (T, error) {
+	if l.start() {
+		l.do()
+	} else {
+		<-l.channel()
+	}
+	return l.value, l.err
+}
+
+// GetContext is like Get, but it waits for the initialization or the given context
+// to be done. If the given context is done first, the error of the context is
+// returned, and the initialization continues. If the value is not being initialized,
+// the initialization is started in a new coroutine, other calls only wait for it.
+func (l *Lazy[T]) GetContext(ctx context.Context) (T, error) {
+	if l.start() {
+		go l.do()
+	}
+	if err := waitContext(ctx, l.channel()); err != nil {
+		var zero T
+		return zero, err
+	}
+	return l.value, l.err
+}
+
+// Reports whether the current coroutine starts the initialization.
+func (l *Lazy[T]) start() bool {
+	return atomic.CompareAndSwapInt32(&l.started, 0, 1)
+}
+
+// Returns the channel that is closed after the initialization.
+func (l *Lazy[T]) channel() chan struct{} {
+	l.once.Do(func() { l.done = make(chan struct{}) })
+	return l.done
+}
+
+// Run the initialization function.
+func (l *Lazy[T]) do() {
+	// The channel must be created before it is closed.
+	defer close(l.channel())
+	if l.init != nil {
+		l.err = SafeCall(func() (err error) {
+			l.value, err = l.init()
+			return
+		})
+	}
+}
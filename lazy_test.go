@@ -0,0 +1,96 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewLazy(t *testing.T) {
+	var n int64
+	l := NewLazy(func() (int, error) {
+		atomic.AddInt64(&n, 1)
+		return 1, nil
+	})
+
+	wg := new(sync.WaitGroup)
+	wg.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer wg.Done()
+			if v, err := l.Get(); v != 1 || err != nil {
+				t.Errorf("Lazy.Get(): %d %v", v, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if v, err := l.GetContext(context.Background()); v != 1 || err != nil {
+		t.Fatalf("Lazy.GetContext(): %d %v", v, err)
+	}
+	if got := atomic.LoadInt64(&n); got != 1 {
+		t.Fatalf("Lazy.Get(): %d", got)
+	}
+}
+
+func TestNewLazy_Error(t *testing.T) {
+	want := errors.New("test")
+	l := NewLazy(func() (string, error) { return "", want })
+	if _, err := l.Get(); err != want {
+		t.Fatalf("Lazy.Get(): %v", err)
+	}
+
+	l = NewLazy(func() (string, error) { panic("test") })
+	if _, err := l.Get(); !IsPanicError(err) {
+		t.Fatalf("Lazy.Get(): %v", err)
+	}
+}
+
+func TestLazy_GetContext(t *testing.T) {
+	release := make(chan struct{})
+	l := NewLazy(func() (int, error) {
+		<-release
+		return 1, nil
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if _, err := l.GetContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("Lazy.GetContext(): %v", err)
+	}
+
+	close(release)
+	if v, err := l.GetContext(context.Background()); v != 1 || err != nil {
+		t.Fatalf("Lazy.GetContext(): %d %v", v, err)
+	}
+}
+
+func TestLazy_Zero(t *testing.T) {
+	var l Lazy[int]
+	if v, err := l.Get(); v != 0 || err != nil {
+		t.Fatalf("Lazy.Get(): %d %v", v, err)
+	}
+	if v, err := l.GetContext(context.Background()); v != 0 || err != nil {
+		t.Fatalf("Lazy.GetContext(): %d %v", v, err)
+	}
+}
@@ -0,0 +1,35 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"log"
+)
+
+// Logger interface defines the logger used by the runner to report the errors
+// that are not returned to the caller. The *log.Logger implements this interface.
+type Logger interface {
+	// Printf prints a log message in the manner of fmt.Printf.
+	Printf(format string, args ...interface{})
+}
+
+// The stdLogger type is the default logger of the runner.
+// It writes log messages to the standard logger of the log package.
+type stdLogger struct{}
+
+// Printf prints a log message to the standard logger.
+func (stdLogger) Printf(format string, args ...interface{}) {
+	log.Printf(format, args...)
+}
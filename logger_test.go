@@ -0,0 +1,54 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// The testLogger type records the log messages for tests.
+type testLogger struct {
+	mutex    sync.Mutex
+	messages []string
+}
+
+func (l *testLogger) Printf(format string, args ...interface{}) {
+	l.mutex.Lock()
+	l.messages = append(l.messages, fmt.Sprintf(format, args...))
+	l.mutex.Unlock()
+}
+
+func (l *testLogger) Messages() []string {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return append([]string(nil), l.messages...)
+}
+
+func TestStdLogger(t *testing.T) {
+	buf := new(bytes.Buffer)
+	log.SetOutput(buf)
+	defer log.SetOutput(os.Stderr)
+
+	stdLogger{}.Printf("test %d", 1)
+	if s := buf.String(); !strings.Contains(s, "test 1") {
+		t.Fatalf("stdLogger.Printf(): %s", s)
+	}
+}
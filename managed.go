@@ -0,0 +1,46 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync/atomic"
+)
+
+// The number of the running coroutines managed by the task adapters.
+var managedGoroutines int64
+
+// ActiveGoroutines returns the number of the coroutines managed by the task adapters
+// of this package that are still running, such as the coroutines started by the tasks
+// created by the NewService, NewHealthMonitorTask and NewWaitTask functions.
+// It is designed for the tests to assert that no managed coroutine is leaked after
+// the runner exits. It is safe to be called concurrently.
+func ActiveGoroutines() int {
+	return int(atomic.LoadInt64(&managedGoroutines))
+}
+
+// Runs the given function in a managed coroutine, which is counted by the
+// ActiveGoroutines function until the function returns. The given exit function is
+// called in the coroutine after it is no longer counted, so the shutdown that waits
+// for the signal of the exit function observes the decremented count, it can be nil.
+func goManaged(f func(), exit func()) {
+	atomic.AddInt64(&managedGoroutines, 1)
+	go func() {
+		f()
+		atomic.AddInt64(&managedGoroutines, -1)
+		if exit != nil {
+			exit()
+		}
+	}()
+}
@@ -0,0 +1,46 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestActiveGoroutines(t *testing.T) {
+	base := ActiveGoroutines()
+
+	s := new(testService)
+	s.start = func(ctx context.Context, ready func()) error {
+		ready()
+		<-ctx.Done()
+		return nil
+	}
+	r := New()
+	r.MustRun(NewService(s))
+	r.MustRun(NewHealthMonitorTask(func(context.Context) error { return nil }, time.Hour, 1, nil))
+	r.MustRun(NewWaitTask(New(), nil))
+	if n := ActiveGoroutines() - base; n != 3 {
+		t.Fatalf("ActiveGoroutines(): %d", n)
+	}
+
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if n := ActiveGoroutines() - base; n != 0 {
+		t.Fatalf("ActiveGoroutines(): %d", n)
+	}
+}
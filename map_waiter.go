@@ -0,0 +1,38 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package runner
+
+// MapWaiter returns a channel that receives the given value once when the given
+// waiter is closed, and is then closed. The channel is buffered, so the forwarding
+// coroutine exits right after the waiter is closed even if no one receives the value.
+// If the given waiter is already closed, the value is sent immediately.
+func MapWaiter[T any](w Waiter, value T) <-chan T {
+	c := make(chan T, 1)
+	select {
+	case <-w.Channel():
+		c <- value
+		close(c)
+	default:
+		go func() {
+			w.Wait()
+			c <- value
+			close(c)
+		}()
+	}
+	return c
+}
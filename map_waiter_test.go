@@ -0,0 +1,48 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package runner
+
+import (
+	"testing"
+)
+
+func TestMapWaiter(t *testing.T) {
+	w := NewCloseableWaiter()
+	c := MapWaiter(w.Waiter(), "closed")
+	select {
+	case v := <-c:
+		t.Fatalf("MapWaiter(): %s", v)
+	default:
+	}
+	w.Close()
+	if v := <-c; v != "closed" {
+		t.Fatalf("MapWaiter(): %s", v)
+	}
+	if _, ok := <-c; ok {
+		t.Fatal("MapWaiter(): not closed")
+	}
+
+	// The waiter is already closed.
+	c2 := MapWaiter(w, 2)
+	if v, ok := <-c2; !ok || v != 2 {
+		t.Fatalf("MapWaiter(): %d", v)
+	}
+	if _, ok := <-c2; ok {
+		t.Fatal("MapWaiter(): not closed")
+	}
+}
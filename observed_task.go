@@ -0,0 +1,94 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"time"
+)
+
+// NewObservedTask creates a task that logs and times the execution and shutdown
+// of the given task with the given name through the given logger.
+// If the given task panics, the panic is captured and logged, and returned as a
+// PanicError. If the given name is not empty, the returned task implements the
+// NamedTask interface, so the runner annotates its errors with the name. Otherwise
+// the name of the given task is logged, and the errors are annotated only if the
+// given task is named. The other interfaces of the given task, such as PhasedTask,
+// ContextTask and RunnerAwareTask, are still used by the runner.
+func NewObservedTask(t Task, name string, logger Logger) Task {
+	o := &observedTask{task: t, name: name, logger: logger}
+	if name == "" {
+		o.name = taskName(t)
+		return o
+	}
+	return &namedObservedTask{o}
+}
+
+// The observedTask type is the task wrapper that logs and times the given task.
+type observedTask struct {
+	task   Task
+	name   string
+	logger Logger
+}
+
+// The namedObservedTask type is the observed task with the name given by the user.
+type namedObservedTask struct {
+	*observedTask
+}
+
+// Name method returns the name of the current task.
+func (t *namedObservedTask) Name() string {
+	return t.name
+}
+
+// Returns the wrapped task.
+func (t *observedTask) unwrap() []Task {
+	return []Task{t.task}
+}
+
+// Execute method executes the wrapped task and logs the result.
+func (t *observedTask) Execute() error {
+	return t.observe("execute", t.task.Execute)
+}
+
+// Executes the wrapped task with the given context and runner, and logs the result.
+func (t *observedTask) executeWith(ctx context.Context, r TaskRunner) error {
+	return t.observe("execute", func() error { return callExecute(ctx, t.task, r) })
+}
+
+// Shutdown method shuts down the wrapped task and logs the result.
+func (t *observedTask) Shutdown() error {
+	return t.observe("shutdown", t.task.Shutdown)
+}
+
+// ShutdownContext method shuts down the wrapped task with the given context, and
+// logs the result.
+func (t *observedTask) ShutdownContext(ctx context.Context) error {
+	return t.observe("shutdown", func() error { return callShutdown(ctx, t.task) })
+}
+
+// Calls the given function of the wrapped task and logs the result and duration.
+func (t *observedTask) observe(phase string, f func() error) error {
+	t.logger.Printf("runner: starting %s %s", phase, t.name)
+	start := time.Now()
+	err := SafeCall(f)
+	d := time.Since(start)
+	if err != nil {
+		t.logger.Printf("runner: %s %s failed in %s: %s", phase, t.name, d, err)
+		return err
+	}
+	t.logger.Printf("runner: %s %s succeeded in %s", phase, t.name, d)
+	return nil
+}
@@ -0,0 +1,105 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestNewObservedTask(t *testing.T) {
+	logger := new(testLogger)
+	task := NewObservedTask(NewTaskFromFunc(nil, func() error {
+		return errors.New("test")
+	}), "cache", logger)
+
+	if name := taskName(task); name != "cache" {
+		t.Fatalf("NewObservedTask(): name %s", name)
+	}
+	if err := task.Execute(); err != nil {
+		t.Fatalf("NewObservedTask(): Execute() %s", err)
+	}
+	if err := task.Shutdown(); err == nil || err.Error() != "test" {
+		t.Fatalf("NewObservedTask(): Shutdown() %v", err)
+	}
+
+	prefixes := []string{
+		"runner: starting execute cache",
+		"runner: execute cache succeeded in ",
+		"runner: starting shutdown cache",
+		"runner: shutdown cache failed in ",
+	}
+	messages := logger.Messages()
+	if len(messages) != len(prefixes) {
+		t.Fatalf("NewObservedTask(): %v", messages)
+	}
+	for i, prefix := range prefixes {
+		if !strings.HasPrefix(messages[i], prefix) {
+			t.Fatalf("NewObservedTask(): [%d] %s", i, messages[i])
+		}
+	}
+	if !strings.HasSuffix(messages[3], ": test") {
+		t.Fatalf("NewObservedTask(): %s", messages[3])
+	}
+}
+
+func TestNewObservedTask_Panic(t *testing.T) {
+	logger := new(testLogger)
+	task := NewObservedTask(NewTaskFromFunc(func() error {
+		panic("boom")
+	}), "db", logger)
+
+	if err := task.Execute(); !IsPanicError(err) {
+		t.Fatalf("NewObservedTask(): Execute() %v", err)
+	}
+	messages := logger.Messages()
+	if len(messages) != 2 || !strings.HasPrefix(messages[1], "runner: execute db failed in ") ||
+		!strings.HasSuffix(messages[1], ": boom") {
+		t.Fatalf("NewObservedTask(): %v", messages)
+	}
+}
+
+func TestNewObservedTask_Transparent(t *testing.T) {
+	logger := new(testLogger)
+
+	// Without a name, the errors of the unnamed task are not annotated.
+	r := New()
+	r.MustRun(NewObservedTask(NewTaskFromFunc(nil, func() error { return errors.New("test") }), "", logger))
+	if err := r.Exit(); err == nil || err.Error() != "test" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+	if messages := logger.Messages(); len(messages) != 4 || messages[0] != "runner: starting execute *runner.funcTask" {
+		t.Fatalf("NewObservedTask(): %v", messages)
+	}
+
+	// The phase of the wrapped task is used.
+	var ss []string
+	r = New(WithPhases("first"))
+	r.MustRun(NewObservedTask(NewTaskFromFunc(nil, func() error {
+		ss = append(ss, "default")
+		return nil
+	}), "default", logger))
+	r.MustRun(NewObservedTask(&testPhasedTask{Task: NewTaskFromFunc(nil, func() error {
+		ss = append(ss, "first")
+		return nil
+	}), phase: "first"}, "first", logger))
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := strings.Join(ss, ","); got != "first,default" {
+		t.Fatalf("NewObservedTask(): %s", got)
+	}
+}
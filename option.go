@@ -0,0 +1,130 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"time"
+)
+
+// Option defines the option of the runner, which is applied by the New function.
+type Option func(*runner)
+
+// WithLogger sets the logger of the runner.
+// By default, the runner writes logs to the standard logger of the log package.
+func WithLogger(logger Logger) Option {
+	return func(r *runner) { r.logger = logger }
+}
+
+// WithShutdownRecoverLogger makes the runner log each recovered Shutdown panic
+// immediately through the runner logger, in addition to aggregating it into the
+// error returned by the Exit method. By default, the panic is only aggregated.
+func WithShutdownRecoverLogger() Option {
+	return func(r *runner) { r.logShutdownPanic = true }
+}
+
+// WithForceExitAfter sets the time limit of the exit of the runner.
+// If the shutdown of all tasks exceeds the given duration, the runner stops waiting,
+// calls onForce with the tasks that have not been shut down (in shutdown order), and
+// the Exit method returns an error list containing the ErrForcedExit error.
+// The runner itself never terminates the process, that is the choice of onForce,
+// and the abandoned shutdown coroutine stops before shutting down the next task.
+// The time limit also covers the waiting for the deferred task executions, see the
+// Runner.RunDeferred method.
+func WithForceExitAfter(d time.Duration, onForce func(pending []Task)) Option {
+	return func(r *runner) {
+		r.forceExitAfter = d
+		r.onForceExit = onForce
+	}
+}
+
+// WithPhases sets the named shutdown phases of the runner in the shutdown order.
+// When the runner exits, the phases are shut down in the given order, and the tasks
+// in each phase are shut down in reverse order of registration. A task declares its
+// phase by implementing the PhasedTask interface, the tasks without a phase, or with
+// a phase that is not given, are shut down in the last default phase.
+func WithPhases(order ...string) Option {
+	return func(r *runner) { r.phases = order }
+}
+
+// WithDedupeShutdownErrors makes the runner collapse the shutdown errors with the
+// same message into one, only the first occurrence is kept. By default, all the
+// shutdown errors are returned by the Exit method.
+func WithDedupeShutdownErrors() Option {
+	return func(r *runner) { r.dedupeShutdownErrors = true }
+}
+
+// WithGracePeriod sets the shutdown grace period of the RunUntil method.
+// When the RunUntil method exits the runner, the deadline of the shutdown context is
+// the given duration after the exit starts. By default, the remaining budget of the
+// context given to the RunUntil method is used.
+func WithGracePeriod(d time.Duration) Option {
+	return func(r *runner) { r.gracePeriod = d }
+}
+
+// WithExitProgress sets the exit progress callback of the runner.
+// When the runner exits, the callback is called before and after each task is shut
+// down with the number of the tasks that have been shut down, the total number of
+// the tasks, and the task being shut down. The callback is called with panic
+// protection, and the panic is only logged.
+func WithExitProgress(progress func(done, total int, current Task)) Option {
+	return func(r *runner) { r.exitProgress = progress }
+}
+
+// WithTestMode makes the runner exit immediately in the wait methods (Wait, WaitBy,
+// WaitByReason, WaitFor, WaitSignals and RunUntil) instead of blocking, as if the
+// stop source is triggered, so the tests can assert the startup and shutdown of the
+// tasks without sending signals. This option is only for tests, never use it in
+// production.
+func WithTestMode() Option {
+	return func(r *runner) { r.testMode = true }
+}
+
+// WithHardShutdownDeadline sets the hard shutdown deadline of each task of the runner.
+// Unlike the cooperative context timeouts, if the shutdown of a task does not return
+// within the given duration, the runner stops waiting for it, records the
+// ErrShutdownTimeout error for it, and proceeds to shut down the remaining tasks, even
+// if the task ignores its context. The shutdown coroutine of the task is abandoned,
+// since Go can not kill a coroutine, it keeps running until the shutdown returns.
+func WithHardShutdownDeadline(d time.Duration) Option {
+	return func(r *runner) { r.hardShutdownDeadline = d }
+}
+
+// WithEventHistory makes the runner retain the last n lifecycle events, which can be
+// retrieved by the Runner.History method without subscribing to the event stream.
+// By default, no event is retained.
+func WithEventHistory(n int) Option {
+	return func(r *runner) { r.historySize = n }
+}
+
+// WithTaskDecorator adds a decorator of the tasks of the runner, which is applied to
+// each task registered by the Run, RunContext, RunDeferred and RunSupervised methods
+// before it is executed, and the returned task is executed and shut down instead.
+// Multiple decorators are applied in order, the later decorator wraps the result of
+// the previous one. For example, it can wrap all the tasks with a timing middleware.
+// The declarative interfaces of the original task (NamedTask, OptionalTask, PhasedTask,
+// TimeoutTask and WeightedTask) are still used if the decorated task does not implement
+// them, but the behavioral interfaces (ContextTask, ExecuteContextTask and
+// RunnerAwareTask) are only used if the decorated task implements them, so the
+// decorator must forward them if the original task needs the contexts or the runner.
+func WithTaskDecorator(decorator func(Task) Task) Option {
+	return func(r *runner) { r.taskDecorators = append(r.taskDecorators, decorator) }
+}
+
+// WithErrorSeparator sets the separator of the error messages of the aggregate errors
+// returned by the runner, such as the error returned by the Exit method.
+// By default, the separator is "; ".
+func WithErrorSeparator(sep string) Option {
+	return func(r *runner) { r.errorSeparator = sep }
+}
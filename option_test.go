@@ -0,0 +1,343 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWithShutdownRecoverLogger(t *testing.T) {
+	logger := new(testLogger)
+	r := New(WithLogger(logger), WithShutdownRecoverLogger())
+
+	r.MustRun(NewTaskFromFunc(nil, func() error { panic("test panic") }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test error") }))
+
+	if err := r.Exit(); err == nil {
+		t.Fatal("Runner.Exit(): nil")
+	} else {
+		if s := err.Error(); s != "test error; test panic" {
+			t.Fatalf("Runner.Exit(): %s", s)
+		}
+	}
+
+	messages := logger.Messages()
+	if len(messages) != 1 {
+		t.Fatalf("WithShutdownRecoverLogger(): %v", messages)
+	}
+	if s := messages[0]; !strings.Contains(s, "task #0") || !strings.Contains(s, "test panic") ||
+		!strings.Contains(s, "TestWithShutdownRecoverLogger") {
+		t.Fatalf("WithShutdownRecoverLogger(): %s", s)
+	}
+}
+
+func TestWithLogger(t *testing.T) {
+	logger := new(testLogger)
+	r := New(WithLogger(logger))
+
+	r.MustRun(NewTaskFromFunc(nil, func() error { panic("test panic") }))
+	if err := r.Exit(); err == nil {
+		t.Fatal("Runner.Exit(): nil")
+	}
+	if messages := logger.Messages(); len(messages) != 0 {
+		t.Fatalf("WithLogger(): %v", messages)
+	}
+}
+
+func TestWithForceExitAfter(t *testing.T) {
+	var pending []Task
+	r := New(WithForceExitAfter(time.Millisecond*50, func(tasks []Task) { pending = tasks }))
+
+	release := make(chan struct{})
+	defer close(release)
+
+	var ss []string
+	t1 := NewTaskFromFunc(nil, func() error {
+		ss = append(ss, "B1")
+		return nil
+	})
+	t2 := NewTaskFromFunc(nil, func() error {
+		<-release
+		return nil
+	})
+	t3 := NewTaskFromFunc(nil, func() error { return errors.New("test") })
+	r.MustRun(t1).MustRun(t2).MustRun(t3)
+
+	err := r.Exit()
+	if err == nil {
+		t.Fatal("Runner.Exit(): nil")
+	}
+	if errs, ok := err.(*Errors); !ok || errs.Len() != 2 || !errs.Contains(ErrForcedExit) {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if len(pending) != 2 || pending[0] != t2 || pending[1] != t1 {
+		t.Fatalf("WithForceExitAfter(): %v", pending)
+	}
+	if len(ss) != 0 {
+		t.Fatalf("WithForceExitAfter(): %v", ss)
+	}
+	if !r.Exited() {
+		t.Fatal("Runner.Exited(): false")
+	}
+}
+
+func TestWithForceExitAfterInTime(t *testing.T) {
+	var called bool
+	r := New(WithForceExitAfter(time.Second, func([]Task) { called = true }))
+
+	r.MustRun(NewTaskFromFunc(nil))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test") }))
+
+	if err := r.Exit(); err == nil || err.Error() != "test" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+	if called {
+		t.Fatal("WithForceExitAfter(): called")
+	}
+}
+
+type testPhasedTask struct {
+	Task
+	phase string
+}
+
+func (t *testPhasedTask) Phase() string { return t.phase }
+
+func TestWithPhases(t *testing.T) {
+	r := New(WithPhases("traffic", "workers", "storage"))
+
+	var ss []string
+	add := func(name, phase string) {
+		task := NewTaskFromFunc(nil, func() error {
+			ss = append(ss, name)
+			return nil
+		})
+		if phase == "" {
+			r.MustRun(task)
+		} else {
+			r.MustRun(&testPhasedTask{Task: task, phase: phase})
+		}
+	}
+
+	add("db", "storage")
+	add("cache", "storage")
+	add("other1", "")
+	add("worker", "workers")
+	add("http", "traffic")
+	add("other2", "unknown")
+	add("grpc", "traffic")
+
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := strings.Join(ss, "-"); got != "grpc-http-worker-cache-db-other2-other1" {
+		t.Fatalf("WithPhases(): %s", got)
+	}
+}
+
+func TestWithDedupeShutdownErrors(t *testing.T) {
+	shared := errors.New("pool closed")
+	r := New(WithDedupeShutdownErrors())
+
+	r.MustRun(NewTaskFromFunc(nil, func() error { return shared }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("other") }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("pool closed") }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return shared }))
+
+	if err := r.Exit(); err == nil || err.Error() != "pool closed; other" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+
+	r = New(WithDedupeShutdownErrors())
+	r.MustRun(NewTaskFromFunc(nil, func() error { return shared }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return shared }))
+	if err := r.Exit(); err != shared {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+}
+
+func TestWithExitProgress(t *testing.T) {
+	var ss []string
+	logger := new(testLogger)
+	r := New(WithLogger(logger), WithExitProgress(func(done, total int, current Task) {
+		ss = append(ss, fmt.Sprintf("%d/%d %s", done, total, taskName(current)))
+		if done == 0 {
+			panic("test")
+		}
+	}))
+	r.MustRun(NewObservedTask(NewTaskFromFunc(nil), "db", logger))
+	r.MustRun(NewObservedTask(NewTaskFromFunc(nil), "cache", logger))
+
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := strings.Join(ss, ", "); got != "0/2 cache, 1/2 cache, 1/2 db, 2/2 db" {
+		t.Fatalf("WithExitProgress(): %s", got)
+	}
+	var panics int
+	for _, s := range logger.Messages() {
+		if s == "runner: exit progress callback panic: test" {
+			panics++
+		}
+	}
+	if panics != 1 {
+		t.Fatalf("WithExitProgress(): %v", logger.Messages())
+	}
+}
+
+func TestWithTestMode(t *testing.T) {
+	var ss []string
+	newRunner := func() Runner {
+		r := New(WithTestMode())
+		r.MustRun(NewTaskFromFunc(func() error {
+			ss = append(ss, "execute")
+			return nil
+		}, func() error {
+			ss = append(ss, "shutdown")
+			return nil
+		}))
+		return r
+	}
+
+	if err := newRunner().Wait(); err != nil {
+		t.Fatalf("Runner.Wait(): %s", err)
+	}
+	if reason, err := newRunner().WaitByReason(make(chan struct{})); err != nil || reason != ReasonChannel {
+		t.Fatalf("Runner.WaitByReason(): %s %v", reason, err)
+	}
+	if err := newRunner().WaitFor(NewCloseableWaiter()); err != nil {
+		t.Fatalf("Runner.WaitFor(): %s", err)
+	}
+	if err := newRunner().RunUntil(context.Background()); err != nil {
+		t.Fatalf("Runner.RunUntil(): %s", err)
+	}
+	if got := strings.Join(ss, "-"); got != strings.Repeat("execute-shutdown-", 3)+"execute-shutdown" {
+		t.Fatalf("WithTestMode(): %s", got)
+	}
+}
+
+func TestWithHardShutdownDeadline(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var shutdown bool
+	r := New(WithHardShutdownDeadline(time.Millisecond * 20))
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		shutdown = true
+		return nil
+	}))
+	// The non-cooperative task ignores its context.
+	r.MustRun(&testContextTask{Task: NewTaskFromFunc(nil), shutdown: func(context.Context) error {
+		<-release
+		return nil
+	}})
+
+	if err := r.Exit(); err != ErrShutdownTimeout {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+	if !shutdown {
+		t.Fatal("WithHardShutdownDeadline(): remaining task not shut down")
+	}
+}
+
+func TestWithErrorSeparator(t *testing.T) {
+	newRunner := func(options ...Option) Runner {
+		r := New(options...)
+		r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test1") }))
+		r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test2") }))
+		r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test2") }))
+		return r
+	}
+
+	if err := newRunner(WithErrorSeparator("\n")).Exit(); err == nil || err.Error() != "test2\ntest2\ntest1" {
+		t.Fatalf("Runner.Exit(): %q", err)
+	}
+	if err := newRunner(WithErrorSeparator("\n"), WithDedupeShutdownErrors()).Exit(); err == nil ||
+		err.Error() != "test2\ntest1" {
+		t.Fatalf("Runner.Exit(): %q", err)
+	}
+	if err := newRunner().Exit(); err == nil || err.Error() != "test2; test2; test1" {
+		t.Fatalf("Runner.Exit(): %q", err)
+	}
+}
+
+func TestWithTaskDecorator(t *testing.T) {
+	var calls int
+	var ss []string
+	decorator := func(name string) func(Task) Task {
+		return func(t Task) Task {
+			calls++
+			return NewNamedTaskFromFunc(name, t.Execute, func() error {
+				ss = append(ss, name)
+				return t.Shutdown()
+			})
+		}
+	}
+	r := New(WithTaskDecorator(decorator("A")), WithTaskDecorator(decorator("B")))
+
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		ss = append(ss, "task")
+		return nil
+	}))
+	if calls != 2 {
+		t.Fatalf("WithTaskDecorator(): calls %d", calls)
+	}
+	if tasks := r.Tasks(); len(tasks) != 1 || taskName(tasks[0]) != "B" {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := strings.Join(ss, ","); got != "B,A,task" {
+		t.Fatalf("WithTaskDecorator(): %s", got)
+	}
+}
+
+func TestWithTaskDecorator_Interfaces(t *testing.T) {
+	var ss []string
+	record := func(s string) func() error {
+		return func() error {
+			ss = append(ss, s)
+			return nil
+		}
+	}
+	// The decorator does not forward the interfaces of the original task.
+	decorator := func(t Task) Task { return NewTaskFromFunc(t.Execute, t.Shutdown) }
+	r := New(WithPhases("first"), WithTaskDecorator(decorator))
+
+	r.MustRun(NewTaskFromFunc(nil, record("default")))
+	r.MustRun(&testPhasedTask{Task: NewTaskFromFunc(nil, record("first")), phase: "first"})
+	if err := r.Run(&testOptionalTask{
+		Task:     NewTaskFromFunc(func() error { return errors.New("test") }),
+		optional: true,
+	}); err != nil {
+		t.Fatalf("Runner.Run(): %s", err)
+	}
+	// The decorated task is exposed.
+	if tasks := r.Tasks(); len(tasks) != 2 || taskName(tasks[0]) != "*runner.funcTask" {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := strings.Join(ss, ","); got != "first,default" {
+		t.Fatalf("WithTaskDecorator(): %s", got)
+	}
+}
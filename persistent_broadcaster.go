@@ -0,0 +1,128 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync"
+)
+
+// PersistentBroadcaster interface defines the broadcaster whose subscribers receive
+// every broadcast until they unsubscribe, without creating a waiter for each one.
+type PersistentBroadcaster interface {
+	// Subscribe registers a new subscriber, and returns the channel that receives a
+	// closed waiter for each subsequent broadcast and the function that unsubscribes it.
+	// The subscriber must call the Done method of each received waiter after handling
+	// the broadcast, which is the acknowledgement of the broadcast.
+	// The channel is closed after the subscriber is unsubscribed or the broadcaster
+	// is closed, so the subscriber can range over it. The unsubscribe function is
+	// idempotent. If the broadcaster is closed, the returned channel is closed.
+	Subscribe() (<-chan ReceiptableWaiter, func())
+
+	// Broadcast sends a signal to all the subscribers in reverse order of subscription,
+	// and waits for each subscriber to call the Done method of the received waiter.
+	// The subscriber that unsubscribes during the broadcast is skipped.
+	Broadcast()
+
+	// Close closes the current broadcaster and the channels of all the subscribers.
+	// After this method returns, the Subscribe method always returns a closed channel.
+	Close()
+}
+
+// NewPersistentBroadcaster creates and returns a new PersistentBroadcaster instance.
+func NewPersistentBroadcaster() PersistentBroadcaster {
+	return new(persistentBroadcaster)
+}
+
+// The built-in implementation of the PersistentBroadcaster interface.
+type persistentBroadcaster struct {
+	mutex       sync.Mutex
+	subscribers []*persistentSubscriber
+	closed      bool
+}
+
+// The persistentSubscriber type is the subscriber of the persistent broadcaster.
+type persistentSubscriber struct {
+	c chan ReceiptableWaiter
+	// The waiter is closed when the subscriber unsubscribes, so the broadcast in
+	// progress stops waiting for it.
+	quit CloseableWaiter
+}
+
+// Subscribe registers a new subscriber, and returns the channel that receives a
+// closed waiter for each subsequent broadcast and the function that unsubscribes it.
+func (b *persistentBroadcaster) Subscribe() (<-chan ReceiptableWaiter, func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	s := &persistentSubscriber{c: make(chan ReceiptableWaiter), quit: NewCloseableWaiter()}
+	if b.closed {
+		close(s.c)
+		return s.c, func() {}
+	}
+	b.subscribers = append(b.subscribers, s)
+	return s.c, func() { b.unsubscribe(s) }
+}
+
+// Unsubscribe the given subscriber and close its channel.
+func (b *persistentBroadcaster) unsubscribe(s *persistentSubscriber) {
+	// Release the broadcast in progress before waiting for the lock.
+	s.quit.Close()
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for i := range b.subscribers {
+		if b.subscribers[i] == s {
+			b.subscribers = append(b.subscribers[:i:i], b.subscribers[i+1:]...)
+			// No broadcast is sending to the channel while the lock is held.
+			close(s.c)
+			return
+		}
+	}
+}
+
+// Broadcast sends a signal to all the subscribers in reverse order of subscription,
+// and waits for each subscriber to call the Done method of the received waiter.
+func (b *persistentBroadcaster) Broadcast() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	for i := len(b.subscribers) - 1; i >= 0; i-- {
+		s := b.subscribers[i]
+		w := newDuplexWaiter()
+		w.Close()
+		select {
+		case s.c <- w.Waiter():
+		case <-s.quit.Channel():
+			continue
+		}
+		select {
+		case <-w.DoneChannel():
+		case <-s.quit.Channel():
+		}
+	}
+}
+
+// Close closes the current broadcaster and the channels of all the subscribers.
+func (b *persistentBroadcaster) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.closed = true
+	for _, s := range b.subscribers {
+		close(s.c)
+	}
+	b.subscribers = nil
+}
@@ -0,0 +1,108 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestPersistentBroadcaster(t *testing.T) {
+	b := NewPersistentBroadcaster()
+
+	var ss []string
+	mutex := new(sync.Mutex)
+	wg := new(sync.WaitGroup)
+	subscribe := func(name string) func() {
+		c, unsubscribe := b.Subscribe()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for w := range c {
+				mutex.Lock()
+				ss = append(ss, name)
+				mutex.Unlock()
+				w.Done()
+			}
+		}()
+		return unsubscribe
+	}
+	unsubscribeA := subscribe("A")
+	subscribe("B")
+
+	// The broadcasts are received in reverse order of subscription.
+	b.Broadcast()
+	b.Broadcast()
+	unsubscribeA()
+	unsubscribeA()
+	b.Broadcast()
+	b.Close()
+	wg.Wait()
+
+	// Each broadcast waits for the subscriber to call Done before the next one.
+	if got := strings.Join(ss, ""); got != "BABAB" {
+		t.Fatalf("PersistentBroadcaster.Broadcast(): %s", got)
+	}
+
+	c, unsubscribe := b.Subscribe()
+	if _, ok := <-c; ok {
+		t.Fatal("PersistentBroadcaster.Subscribe(): not closed")
+	}
+	unsubscribe()
+	b.Broadcast()
+}
+
+func TestPersistentBroadcaster_UnsubscribeDuringBroadcast(t *testing.T) {
+	b := NewPersistentBroadcaster()
+	// The subscriber never receives, and unsubscribes during the broadcast.
+	_, unsubscribe := b.Subscribe()
+	c, _ := b.Subscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Broadcast()
+	}()
+	(<-c).Done()
+	unsubscribe()
+	<-done
+}
+
+func TestPersistentBroadcaster_WaitDone(t *testing.T) {
+	b := NewPersistentBroadcaster()
+	c, unsubscribe := b.Subscribe()
+	defer unsubscribe()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.Broadcast()
+	}()
+	w := <-c
+	select {
+	case <-w.Channel():
+	default:
+		t.Fatal("PersistentBroadcaster.Broadcast(): waiter not closed")
+	}
+	select {
+	case <-done:
+		t.Fatal("PersistentBroadcaster.Broadcast(): returned before Done")
+	case <-time.After(time.Millisecond * 20):
+	}
+	w.Done()
+	<-done
+}
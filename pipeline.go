@@ -0,0 +1,126 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync"
+)
+
+// PipelineStage defines a stage of the pipeline.
+type PipelineStage struct {
+	// Runner is the runner of the current stage.
+	Runner Runner
+
+	// Start runs the tasks of the current stage on the given runner, it can be nil.
+	// The stage is considered fully up when this function returns nil, so the tasks
+	// should be run synchronously by the Runner.Run method.
+	Start func(Runner) error
+}
+
+// Pipeline interface defines the sequential pipeline of runners.
+// Unlike running tasks in the same runner, the pipeline enforces strict ordering
+// between whole runners, the next stage starts only after the previous stage is
+// fully up, and the stages are exited in reverse order.
+type Pipeline interface {
+	// Start starts the stages in order. If a stage fails to start, the stages that
+	// have been started (including the failed one) are exited in reverse order, and
+	// the start error is returned with the exit errors.
+	Start() error
+
+	// Wait blocks the current coroutine until any stage exits, then exits all the
+	// stages in reverse order and returns the exit errors.
+	Wait() error
+
+	// Exit exits all the started stages in reverse order and returns the exit errors.
+	Exit() error
+}
+
+// NewPipeline creates and returns a new Pipeline instance with the given stages.
+func NewPipeline(stages ...PipelineStage) Pipeline {
+	return &pipeline{stages: stages}
+}
+
+// The built-in Pipeline.
+type pipeline struct {
+	mutex   sync.Mutex
+	stages  []PipelineStage
+	started int
+}
+
+// Start starts the stages in order. If a stage fails to start, the stages that
+// have been started (including the failed one) are exited in reverse order, and
+// the start error is returned with the exit errors.
+func (p *pipeline) Start() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	for p.started < len(p.stages) {
+		stage := p.stages[p.started]
+		p.started++
+
+		var err error
+		if stage.Runner.Exited() {
+			err = ErrExited
+		} else if stage.Start != nil {
+			err = SafeCall(func() error { return stage.Start(stage.Runner) })
+		}
+		if err != nil {
+			errs := new(Errors)
+			errs.Add(err)
+			errs.Add(p.exit())
+			return errs.result()
+		}
+	}
+	return nil
+}
+
+// Wait blocks the current coroutine until any stage exits, then exits all the
+// stages in reverse order and returns the exit errors.
+func (p *pipeline) Wait() error {
+	if n := len(p.stages); n > 0 {
+		exited := make(chan struct{})
+		stop := make(chan struct{})
+		once := new(sync.Once)
+		for i := 0; i < n; i++ {
+			go func(c <-chan struct{}) {
+				select {
+				case <-c:
+					once.Do(func() { close(exited) })
+				case <-stop:
+				}
+			}(p.stages[i].Runner.Done())
+		}
+		<-exited
+		close(stop)
+	}
+	return p.Exit()
+}
+
+// Exit exits all the started stages in reverse order and returns the exit errors.
+func (p *pipeline) Exit() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	return p.exit()
+}
+
+// Exits the started stages in reverse order.
+func (p *pipeline) exit() error {
+	errs := new(Errors)
+	for i := p.started - 1; i >= 0; i-- {
+		errs.Add(p.stages[i].Runner.Exit())
+	}
+	return errs.result()
+}
@@ -0,0 +1,86 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestPipeline(t *testing.T) {
+	var logs []string
+	newStage := func(name string, err error) PipelineStage {
+		return PipelineStage{Runner: New(), Start: func(r Runner) error {
+			logs = append(logs, "start "+name)
+			if err != nil {
+				return err
+			}
+			return r.Run(NewTaskFromFunc(nil, func() error {
+				logs = append(logs, "exit "+name)
+				return fmt.Errorf("%s exit", name)
+			}))
+		}}
+	}
+
+	p := NewPipeline(newStage("infra", nil), newStage("app", nil))
+	if err := p.Start(); err != nil {
+		t.Fatalf("Pipeline.Start(): %s", err)
+	}
+	if err := p.Exit(); err == nil || err.Error() != "app exit; infra exit" {
+		t.Fatalf("Pipeline.Exit(): %v", err)
+	}
+	if s := fmt.Sprint(logs); s != "[start infra start app exit app exit infra]" {
+		t.Fatalf("Pipeline: %s", s)
+	}
+
+	// The later stage fails to start.
+	logs = nil
+	p = NewPipeline(newStage("infra", nil), newStage("app", errors.New("app start")), newStage("web", nil))
+	if err := p.Start(); err == nil || err.Error() != "app start; infra exit" {
+		t.Fatalf("Pipeline.Start(): %v", err)
+	}
+	if s := fmt.Sprint(logs); s != "[start infra start app exit infra]" {
+		t.Fatalf("Pipeline: %s", s)
+	}
+
+	// The exited runner can not be started.
+	r := New()
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if err := NewPipeline(PipelineStage{Runner: r}).Start(); err != ErrExited {
+		t.Fatalf("Pipeline.Start(): %v", err)
+	}
+}
+
+func TestPipeline_Wait(t *testing.T) {
+	infra, app := New(), New()
+	p := NewPipeline(PipelineStage{Runner: infra}, PipelineStage{Runner: app})
+	if err := p.Start(); err != nil {
+		t.Fatalf("Pipeline.Start(): %s", err)
+	}
+	go func() {
+		if err := infra.Exit(); err != nil {
+			t.Errorf("Runner.Exit(): %s", err)
+		}
+	}()
+	if err := p.Wait(); err != nil {
+		t.Fatalf("Pipeline.Wait(): %s", err)
+	}
+	if !app.Exited() {
+		t.Fatal("Pipeline.Wait(): app not exited")
+	}
+}
@@ -0,0 +1,58 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"time"
+)
+
+// NewPolledWaiter creates and returns a waiter that is closed when the given condition
+// function first returns true. The condition is checked immediately, and then polled
+// every interval in a separate coroutine, so the waiter may be closed up to one interval
+// after the condition becomes true. Closing the returned waiter explicitly stops the
+// polling, callers must close it when the condition is no longer needed, otherwise
+// the polling coroutine leaks.
+// The given interval must be positive, otherwise this function panics.
+func NewPolledWaiter(interval time.Duration, cond func() bool) CloseableWaiter {
+	if interval <= 0 {
+		panic("NewPolledWaiter(): interval must be a positive duration")
+	}
+	w := newCloseableWaiter()
+	go pollWaiter(w, interval, cond)
+	return w
+}
+
+// Poll the given condition until it returns true or the given waiter is closed.
+func pollWaiter(w *closeableWaiter, interval time.Duration, cond func() bool) {
+	if cond() {
+		w.Close()
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if cond() {
+				w.Close()
+				return
+			}
+		case <-w.Channel():
+			return
+		}
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNewPolledWaiter(t *testing.T) {
+	var n int64
+	w := NewPolledWaiter(time.Millisecond, func() bool { return atomic.AddInt64(&n, 1) >= 3 })
+	if w == nil {
+		t.Fatal("NewPolledWaiter(): nil")
+	}
+	w.Wait()
+	if got := atomic.LoadInt64(&n); got != 3 {
+		t.Fatalf("NewPolledWaiter(): %d", got)
+	}
+	w.Close()
+
+	w = NewPolledWaiter(time.Hour, func() bool { return true })
+	w.Wait()
+}
+
+func TestNewPolledWaiter_Close(t *testing.T) {
+	var n int64
+	w := NewPolledWaiter(time.Millisecond, func() bool {
+		atomic.AddInt64(&n, 1)
+		return false
+	})
+
+	time.Sleep(time.Millisecond * 10)
+	w.Close()
+	w.Wait()
+
+	// Wait for the polling coroutine to observe the close.
+	time.Sleep(time.Millisecond * 10)
+	m := atomic.LoadInt64(&n)
+	time.Sleep(time.Millisecond * 10)
+	if got := atomic.LoadInt64(&n); got != m {
+		t.Fatalf("NewPolledWaiter(): %d != %d", got, m)
+	}
+}
+
+func TestNewPolledWaiter_InvalidInterval(t *testing.T) {
+	defer func() {
+		if v := recover(); v == nil {
+			t.Fatal("NewPolledWaiter(): no panic")
+		}
+	}()
+	NewPolledWaiter(0, func() bool { return true })
+}
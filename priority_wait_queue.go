@@ -0,0 +1,152 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sort"
+	"sync"
+)
+
+// PriorityWaitQueue interface defines the wait queue with priority classes.
+// The waiters are enqueued into the buckets of their priorities, and released from
+// the highest priority non-empty bucket first, FIFO within a bucket. It is worth
+// noting that the waiters of a lower priority are starved as long as the waiters
+// of a higher priority keep coming, the callers should limit the higher priority
+// admission if the lower priority must make progress.
+type PriorityWaitQueue interface {
+	// NewWaiter creates a waiter and adds it to the bucket of the given priority.
+	// The larger the priority, the earlier the waiter is released.
+	NewWaiter(int) Waiter
+
+	// NewReceiptableWaiter creates a receiptable waiter and adds it to the bucket
+	// of the given priority.
+	NewReceiptableWaiter(int) ReceiptableWaiter
+
+	// Len returns the number of waiters in all the buckets.
+	Len() int
+
+	// Release releases up to the top n waiters in the queue, from the highest
+	// priority bucket to the lowest. This method returns the number of released
+	// waiters, the range is [0, n].
+	Release(int) int
+
+	// ReleaseAll releases all the waiters in the queue, from the highest priority
+	// bucket to the lowest. This method returns the number of released waiters.
+	ReleaseAll() int
+}
+
+// NewPriorityWaitQueue creates and returns a new PriorityWaitQueue instance.
+func NewPriorityWaitQueue() PriorityWaitQueue {
+	return &priorityWaitQueue{buckets: make(map[int][]*waitQueueItem)}
+}
+
+// The built-in PriorityWaitQueue.
+type priorityWaitQueue struct {
+	mutex   sync.Mutex
+	buckets map[int][]*waitQueueItem
+	// The priorities of the non-empty buckets in descending order.
+	priorities []int
+	size       int
+}
+
+// NewWaiter creates a waiter and adds it to the bucket of the given priority.
+// The larger the priority, the earlier the waiter is released.
+func (wq *priorityWaitQueue) NewWaiter(priority int) Waiter {
+	w := NewCloseableWaiter()
+	wq.push(priority, &waitQueueItem{waiter: w.Waiter(), closer: w})
+	return w.Waiter()
+}
+
+// NewReceiptableWaiter creates a receiptable waiter and adds it to the bucket
+// of the given priority.
+func (wq *priorityWaitQueue) NewReceiptableWaiter(priority int) ReceiptableWaiter {
+	w := NewDuplexWaiter()
+	wq.push(priority, &waitQueueItem{
+		waiter: w.Waiter(),
+		closer: w,
+		done:   w.DoneChannel(),
+	})
+	return w.Waiter()
+}
+
+// Adds the given item to the bucket of the given priority.
+func (wq *priorityWaitQueue) push(priority int, item *waitQueueItem) {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	if len(wq.buckets[priority]) == 0 {
+		i := sort.Search(len(wq.priorities), func(i int) bool { return wq.priorities[i] < priority })
+		wq.priorities = append(wq.priorities, 0)
+		copy(wq.priorities[i+1:], wq.priorities[i:])
+		wq.priorities[i] = priority
+	}
+	wq.buckets[priority] = append(wq.buckets[priority], item)
+	wq.size++
+}
+
+// Len returns the number of waiters in all the buckets.
+func (wq *priorityWaitQueue) Len() (n int) {
+	wq.mutex.Lock()
+	n = wq.size
+	wq.mutex.Unlock()
+	return
+}
+
+// Release releases up to the top n waiters in the queue, from the highest
+// priority bucket to the lowest. This method returns the number of released
+// waiters, the range is [0, n].
+func (wq *priorityWaitQueue) Release(n int) int {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	return wq.release(n)
+}
+
+// Releases up to the top n waiters in the queue, the mutex must be held.
+func (wq *priorityWaitQueue) release(n int) (r int) {
+	for r < n && len(wq.priorities) > 0 {
+		priority := wq.priorities[0]
+		bucket := wq.buckets[priority]
+		k := len(bucket)
+		if k > n-r {
+			k = n - r
+		}
+		for i := 0; i < k; i++ {
+			bucket[i].Close()
+		}
+		r += k
+		wq.size -= k
+		if k == len(bucket) {
+			delete(wq.buckets, priority)
+			wq.priorities = wq.priorities[1:]
+		} else {
+			rest := make([]*waitQueueItem, len(bucket)-k)
+			copy(rest, bucket[k:])
+			wq.buckets[priority] = rest
+		}
+	}
+	return
+}
+
+// ReleaseAll releases all the waiters in the queue, from the highest priority
+// bucket to the lowest. This method returns the number of released waiters.
+func (wq *priorityWaitQueue) ReleaseAll() int {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	// The waiters are released under one lock, the waiters added concurrently wait
+	// for the lock and are left for the next release.
+	return wq.release(wq.size)
+}
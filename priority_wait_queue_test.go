@@ -0,0 +1,111 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestPriorityWaitQueue(t *testing.T) {
+	wq := NewPriorityWaitQueue()
+	var ws []Waiter
+	var names []string
+	add := func(priority int) {
+		ws = append(ws, wq.NewWaiter(priority))
+		names = append(names, fmt.Sprintf("p%d#%d", priority, len(names)))
+	}
+	// The priorities are interleaved.
+	add(0)
+	add(10)
+	add(5)
+	add(10)
+	add(0)
+	add(5)
+
+	if n := wq.Len(); n != 6 {
+		t.Fatalf("PriorityWaitQueue.Len(): %d", n)
+	}
+
+	released := func() (s []string) {
+		for i, w := range ws {
+			select {
+			case <-w.Channel():
+				s = append(s, names[i])
+			default:
+			}
+		}
+		return
+	}
+
+	if n := wq.Release(3); n != 3 {
+		t.Fatalf("PriorityWaitQueue.Release(): %d", n)
+	}
+	if got := fmt.Sprint(released()); got != "[p10#1 p5#2 p10#3]" {
+		t.Fatalf("PriorityWaitQueue.Release(): %s", got)
+	}
+
+	// The higher priority waiter added later is released first.
+	add(7)
+	if n := wq.Release(2); n != 2 {
+		t.Fatalf("PriorityWaitQueue.Release(): %d", n)
+	}
+	if got := fmt.Sprint(released()); got != "[p10#1 p5#2 p10#3 p5#5 p7#6]" {
+		t.Fatalf("PriorityWaitQueue.Release(): %s", got)
+	}
+	if n := wq.Len(); n != 2 {
+		t.Fatalf("PriorityWaitQueue.Len(): %d", n)
+	}
+
+	done := make(chan struct{})
+	w := wq.NewReceiptableWaiter(-1)
+	go func() {
+		defer close(done)
+		w.Wait()
+		w.Done()
+	}()
+	if n := wq.ReleaseAll(); n != 3 {
+		t.Fatalf("PriorityWaitQueue.ReleaseAll(): %d", n)
+	}
+	<-done
+	if n := wq.Len(); n != 0 {
+		t.Fatalf("PriorityWaitQueue.Len(): %d", n)
+	}
+	if n := wq.Release(1); n != 0 {
+		t.Fatalf("PriorityWaitQueue.Release(): %d", n)
+	}
+}
+
+func TestPriorityWaitQueue_ReleaseAll_Concurrent(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		wq := NewPriorityWaitQueue()
+		low := wq.NewWaiter(0)
+
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			wq.NewWaiter(1)
+		}()
+		wq.ReleaseAll()
+		<-done
+
+		// The waiters added before the release are always released.
+		select {
+		case <-low.Channel():
+		default:
+			t.Fatal("PriorityWaitQueue.ReleaseAll(): not released")
+		}
+	}
+}
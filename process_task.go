@@ -0,0 +1,91 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"time"
+)
+
+// NewProcessTask creates a task that runs the given command as a child process.
+// The Execute method starts the process. The Shutdown method sends the given stop
+// signal to the process and waits up to killTimeout for it to exit, then kills it.
+// The Shutdown method returns the exit error of the process, but exiting because
+// of the stop signal is considered as a normal exit.
+func NewProcessTask(cmd *exec.Cmd, stopSignal os.Signal, killTimeout time.Duration) Task {
+	return &processTask{cmd: cmd, signal: stopSignal, timeout: killTimeout}
+}
+
+// The processTask type is used to manage a child process.
+type processTask struct {
+	cmd     *exec.Cmd
+	signal  os.Signal
+	timeout time.Duration
+	waiter  CloseableWaiter
+	err     error
+}
+
+// Execute method starts the child process.
+func (t *processTask) Execute() error {
+	if err := t.cmd.Start(); err != nil {
+		return err
+	}
+	t.waiter = NewCloseableWaiter()
+	goManaged(t.wait, t.waiter.Close)
+	return nil
+}
+
+// Wait for the child process to exit and save its exit error.
+func (t *processTask) wait() {
+	t.err = t.cmd.Wait()
+}
+
+// Shutdown method stops the child process.
+// If the process does not exit in time after the stop signal is sent, it will be killed.
+func (t *processTask) Shutdown() error {
+	if t.waiter == nil {
+		// The process has not been started.
+		return nil
+	}
+
+	select {
+	case <-t.waiter.Channel():
+		// The process has exited by itself.
+		return t.err
+	default:
+	}
+
+	// If the stop signal can not be sent (not supported on the current platform),
+	// we kill the process directly.
+	if err := t.cmd.Process.Signal(t.signal); err == nil {
+		timer := time.NewTimer(t.timeout)
+		defer timer.Stop()
+
+		select {
+		case <-t.waiter.Channel():
+			if t.isStopped(t.err) {
+				return nil
+			}
+			return t.err
+		case <-timer.C:
+		}
+	}
+
+	// The process may exit just now, so we ignore the error of the Kill method.
+	_ = t.cmd.Process.Kill()
+	t.waiter.Wait()
+	return t.err
+}
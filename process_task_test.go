@@ -0,0 +1,63 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"os/exec"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestNewProcessTask(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("NewProcessTask(): stop signal is not supported on windows")
+	}
+
+	task := NewProcessTask(exec.Command("sleep", "10"), syscall.SIGTERM, time.Second)
+	if err := task.Execute(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("Task.Shutdown(): %s", err)
+	}
+
+	task = NewProcessTask(exec.Command("sh", "-c", `trap "" TERM; sleep 1`), syscall.SIGTERM, time.Millisecond*100)
+	if err := task.Execute(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+	// Make sure the trap is installed.
+	time.Sleep(time.Millisecond * 100)
+	if err := task.Shutdown(); err == nil {
+		t.Fatal("Task.Shutdown(): nil")
+	}
+
+	task = NewProcessTask(exec.Command("sh", "-c", "exit 1"), syscall.SIGTERM, time.Second)
+	if err := task.Execute(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+	time.Sleep(time.Millisecond * 100)
+	if err := task.Shutdown(); err == nil {
+		t.Fatal("Task.Shutdown(): nil")
+	}
+
+	if err := NewProcessTask(exec.Command("sleep", "1"), syscall.SIGTERM, time.Second).Shutdown(); err != nil {
+		t.Fatalf("Task.Shutdown(): %s", err)
+	}
+	if err := NewProcessTask(exec.Command("/not/found"), syscall.SIGTERM, time.Second).Execute(); err == nil {
+		t.Fatal("Task.Execute(): nil")
+	}
+}
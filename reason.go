@@ -0,0 +1,50 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+// ExitReason defines the reason why the waiting of the runner is released.
+type ExitReason int
+
+// These are the exit reasons of the runner.
+const (
+	// ReasonChannel means that the given channel is closed, and the runner is
+	// exited by the waiting method.
+	ReasonChannel ExitReason = iota + 1
+	// ReasonExplicit means that the Exit method of the runner is called explicitly.
+	ReasonExplicit
+	// ReasonSignal means that the exit signal of the operating system is captured,
+	// and the runner is exited by the waiting method.
+	ReasonSignal
+	// ReasonRestart means that the GracefulRestart method of the runner is called.
+	ReasonRestart
+)
+
+// ReasonExitCall is the alias of ReasonExplicit.
+const ReasonExitCall = ReasonExplicit
+
+// String returns the name of the current exit reason.
+func (r ExitReason) String() string {
+	switch r {
+	case ReasonChannel:
+		return "channel"
+	case ReasonExplicit:
+		return "explicit"
+	case ReasonSignal:
+		return "signal"
+	case ReasonRestart:
+		return "restart"
+	}
+	return "unknown"
+}
@@ -0,0 +1,34 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"testing"
+)
+
+func TestExitReason_String(t *testing.T) {
+	items := map[ExitReason]string{
+		ReasonChannel:  "channel",
+		ReasonExplicit: "explicit",
+		ReasonSignal:   "signal",
+		ReasonRestart:  "restart",
+		ExitReason(0):  "unknown",
+	}
+	for r, want := range items {
+		if got := r.String(); got != want {
+			t.Fatalf("ExitReason.String(): %s", got)
+		}
+	}
+}
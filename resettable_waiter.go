@@ -0,0 +1,119 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// ResettableWaiter interface defines the resettable waiter.
+// Unlike the CloseableWaiter, the resettable waiter can be reused for multiple cycles.
+type ResettableWaiter interface {
+	Waiter
+
+	// Close closes the current cycle of the waiter. This method is idempotent.
+	Close()
+
+	// Reset releases the coroutines blocked on the current cycle and starts a new cycle.
+	// Each call to this method increments the generation of the waiter by one.
+	Reset()
+
+	// Generation returns the generation of the current cycle, starting from 0.
+	// If a consumer observes a generation jump of more than 1, it missed a cycle.
+	// This method never blocks.
+	Generation() uint64
+
+	// WaitN blocks the current coroutine until the generation of the waiter reaches
+	// the given generation, and returns the generation observed. Closing the cycle
+	// does not release this method, only the Reset method does.
+	WaitN(uint64) uint64
+}
+
+// NewResettableWaiter creates and returns a new ResettableWaiter instance.
+func NewResettableWaiter() ResettableWaiter {
+	w := new(resettableWaiter)
+	w.cycle.Store(newWaiterCycle(0))
+	return w
+}
+
+// The built-in ResettableWaiter.
+// The current cycle is swapped atomically, so readers never take the lock.
+type resettableWaiter struct {
+	mutex sync.Mutex
+	cycle atomic.Value
+}
+
+// The waiterCycle type represents a cycle of the resettable waiter.
+type waiterCycle struct {
+	*closeableWaiter
+	generation uint64
+	// The channel is closed when the cycle is replaced by the Reset method.
+	reset chan struct{}
+}
+
+// Create and return a new cycle of the given generation.
+func newWaiterCycle(generation uint64) *waiterCycle {
+	return &waiterCycle{
+		closeableWaiter: newCloseableWaiter(),
+		generation:      generation,
+		reset:           make(chan struct{}),
+	}
+}
+
+// Returns the current cycle of the waiter.
+func (w *resettableWaiter) current() *waiterCycle {
+	return w.cycle.Load().(*waiterCycle)
+}
+
+// Wait blocks the current coroutine and waits for the current cycle to be closed.
+func (w *resettableWaiter) Wait() { <-w.Channel() }
+
+// Channel returns a read-only channel of the current cycle that can be used for select.
+func (w *resettableWaiter) Channel() <-chan struct{} {
+	return w.current().Channel()
+}
+
+// Close closes the current cycle of the waiter. This method is idempotent.
+func (w *resettableWaiter) Close() { w.current().Close() }
+
+// Reset releases the coroutines blocked on the current cycle and starts a new cycle.
+// Each call to this method increments the generation of the waiter by one.
+func (w *resettableWaiter) Reset() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	c := w.current()
+	c.Close()
+	w.cycle.Store(newWaiterCycle(c.generation + 1))
+	close(c.reset)
+}
+
+// Generation returns the generation of the current cycle, starting from 0.
+func (w *resettableWaiter) Generation() uint64 {
+	return w.current().generation
+}
+
+// WaitN blocks the current coroutine until the generation of the waiter reaches
+// the given generation, and returns the generation observed.
+func (w *resettableWaiter) WaitN(n uint64) uint64 {
+	for {
+		c := w.current()
+		if c.generation >= n {
+			return c.generation
+		}
+		<-c.reset
+	}
+}
@@ -0,0 +1,135 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNewResettableWaiter(t *testing.T) {
+	w := NewResettableWaiter()
+	if w == nil {
+		t.Fatal("NewResettableWaiter(): nil")
+	}
+	if g := w.Generation(); g != 0 {
+		t.Fatalf("ResettableWaiter.Generation(): %d", g)
+	}
+
+	c := w.Channel()
+	select {
+	case <-c:
+		t.Fatal("ResettableWaiter.Channel(): closed")
+	default:
+	}
+
+	w.Close()
+	w.Close()
+	w.Wait()
+
+	w.Reset()
+	if g := w.Generation(); g != 1 {
+		t.Fatalf("ResettableWaiter.Generation(): %d", g)
+	}
+	select {
+	case <-w.Channel():
+		t.Fatal("ResettableWaiter.Channel(): closed")
+	default:
+	}
+
+	// Reset releases the coroutines blocked on the previous cycle.
+	c = w.Channel()
+	w.Reset()
+	<-c
+	if g := w.Generation(); g != 2 {
+		t.Fatalf("ResettableWaiter.Generation(): %d", g)
+	}
+}
+
+func TestResettableWaiter_Concurrent(t *testing.T) {
+	w := NewResettableWaiter()
+	wg := new(sync.WaitGroup)
+
+	wg.Add(3)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			w.Reset()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			w.Close()
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		var last uint64
+		for i := 0; i < 1000; i++ {
+			if g := w.Generation(); g < last {
+				t.Errorf("ResettableWaiter.Generation(): %d < %d", g, last)
+				return
+			} else {
+				last = g
+			}
+		}
+	}()
+	wg.Wait()
+
+	if g := w.Generation(); g != 1000 {
+		t.Fatalf("ResettableWaiter.Generation(): %d", g)
+	}
+}
+
+func TestResettableWaiter_WaitN(t *testing.T) {
+	w := NewResettableWaiter()
+	if g := w.WaitN(0); g != 0 {
+		t.Fatalf("ResettableWaiter.WaitN(): %d", g)
+	}
+
+	done := make(chan uint64)
+	go func() { done <- w.WaitN(3) }()
+
+	// Closing the cycle does not release the method.
+	w.Close()
+	w.Reset()
+	w.Close()
+	select {
+	case g := <-done:
+		t.Fatalf("ResettableWaiter.WaitN(): %d", g)
+	case <-time.After(time.Millisecond * 10):
+	}
+
+	w.Reset()
+	w.Reset()
+	w.Reset()
+	if g := <-done; g < 3 {
+		t.Fatalf("ResettableWaiter.WaitN(): %d", g)
+	}
+	if g := w.WaitN(2); g != 4 {
+		t.Fatalf("ResettableWaiter.WaitN(): %d", g)
+	}
+}
+
+func BenchmarkResettableWaiter_Reset(b *testing.B) {
+	w := NewResettableWaiter()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		w.Reset()
+	}
+}
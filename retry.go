@@ -0,0 +1,121 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"math"
+	"time"
+)
+
+// Retry calls the given function through the SafeCall function up to the given
+// number of attempts, until it returns nil. If attempts is less than or equal to
+// zero, the given function is retried until it succeeds.
+// The given backoff function returns the duration to wait after the given attempt
+// (starting from 1) fails, it can be nil, which means retrying immediately.
+// If all the attempts fail, an *Errors containing the error of each attempt is returned.
+func Retry(attempts int, backoff func(attempt int) time.Duration, f func() error) error {
+	return RetryUntil(nil, attempts, backoff, f)
+}
+
+// RetryUntil is like Retry, but it also stops retrying when the given channel is
+// closed, in which case an *Errors containing the errors of the completed attempts
+// is returned. The given function is called at least once.
+func RetryUntil(stop <-chan struct{}, attempts int, backoff func(attempt int) time.Duration, f func() error) error {
+	errs := new(Errors)
+	for attempt := 1; ; attempt++ {
+		err := SafeCall(f)
+		if err == nil {
+			return nil
+		}
+		errs.Add(err)
+		if attempt == attempts {
+			return errs
+		}
+
+		var d time.Duration
+		if backoff != nil {
+			d = backoff(attempt)
+		}
+		if d <= 0 {
+			select {
+			case <-stop:
+				return errs
+			default:
+				continue
+			}
+		}
+		timer := time.NewTimer(d)
+		select {
+		case <-stop:
+			timer.Stop()
+			return errs
+		case <-timer.C:
+		}
+	}
+}
+
+// RetryPolicy defines the policy of the RetryCall function.
+type RetryPolicy struct {
+	// Attempts is the maximum number of attempts, if it is less than or equal to
+	// zero, the function is retried until it succeeds.
+	Attempts int
+
+	// Backoff is the duration to wait after the first failed attempt.
+	Backoff time.Duration
+
+	// Multiplier is the factor by which the backoff grows after each failed attempt,
+	// if it is less than or equal to 1, the backoff is constant.
+	Multiplier float64
+
+	// MaxBackoff is the upper limit of the growing backoff, zero means no limit.
+	MaxBackoff time.Duration
+}
+
+// BackoffFunc method returns the backoff function of the current policy, which can
+// be used by the Retry and RetryUntil functions. The growing backoff never overflows,
+// it is limited to the maximum duration if the MaxBackoff is not set.
+func (p RetryPolicy) BackoffFunc() func(attempt int) time.Duration {
+	return func(attempt int) time.Duration {
+		d := p.Backoff
+		if p.Multiplier > 1 && attempt > 1 {
+			// The float is clamped before the conversion, which overflows otherwise.
+			f := float64(d) * math.Pow(p.Multiplier, float64(attempt-1))
+			if f >= math.MaxInt64 {
+				d = math.MaxInt64
+			} else {
+				d = time.Duration(f)
+			}
+		}
+		if p.MaxBackoff > 0 && d > p.MaxBackoff {
+			d = p.MaxBackoff
+		}
+		return d
+	}
+}
+
+// Call method calls the given function with the current policy, see the Retry function.
+func (p RetryPolicy) Call(f func() error) error {
+	return Retry(p.Attempts, p.BackoffFunc(), f)
+}
+
+// RetryCall calls the given function through the SafeCall function up to the given
+// number of attempts, sleeping the given backoff between the attempts, so the panics
+// are retried as the PanicError. It returns nil on the first success, or an *Errors
+// containing the error of each attempt if all the attempts fail. If attempts is less
+// than or equal to zero, the given function is retried until it succeeds.
+// Use the RetryPolicy for the exponential backoff.
+func RetryCall(attempts int, backoff time.Duration, f func() error) error {
+	return RetryPolicy{Attempts: attempts, Backoff: backoff}.Call(f)
+}
@@ -0,0 +1,145 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRetry(t *testing.T) {
+	var calls int
+	err := Retry(3, nil, func() error {
+		calls++
+		if calls < 2 {
+			panic("test")
+		}
+		return nil
+	})
+	if err != nil || calls != 2 {
+		t.Fatalf("Retry(): %v %d", err, calls)
+	}
+
+	var attempts []int
+	calls = 0
+	err = Retry(3, func(attempt int) time.Duration {
+		attempts = append(attempts, attempt)
+		return time.Millisecond
+	}, func() error {
+		calls++
+		return fmt.Errorf("test%d", calls)
+	})
+	if errs, ok := err.(*Errors); !ok || errs.Error() != "test1; test2; test3" {
+		t.Fatalf("Retry(): %v", err)
+	}
+	if fmt.Sprint(attempts) != "[1 2]" {
+		t.Fatalf("Retry(): %v", attempts)
+	}
+
+	// Retry forever until success.
+	calls = 0
+	if err := Retry(0, nil, func() error {
+		if calls++; calls < 100 {
+			return errors.New("test")
+		}
+		return nil
+	}); err != nil || calls != 100 {
+		t.Fatalf("Retry(): %v %d", err, calls)
+	}
+}
+
+func TestRetryUntil(t *testing.T) {
+	stop := make(chan struct{})
+	var calls int
+	time.AfterFunc(time.Millisecond*10, func() { close(stop) })
+	err := RetryUntil(stop, 0, func(int) time.Duration { return time.Hour }, func() error {
+		calls++
+		return errors.New("test")
+	})
+	if errs, ok := err.(*Errors); !ok || errs.Len() != 1 {
+		t.Fatalf("RetryUntil(): %v", err)
+	}
+
+	calls = 0
+	if err := RetryUntil(stop, 0, nil, func() error {
+		calls++
+		return errors.New("test")
+	}); err == nil || calls != 1 {
+		t.Fatalf("RetryUntil(): %v %d", err, calls)
+	}
+}
+
+func TestRetryCall(t *testing.T) {
+	var calls int
+	start := time.Now()
+	err := RetryCall(3, time.Millisecond*10, func() error {
+		if calls++; calls == 1 {
+			panic("test")
+		}
+		return fmt.Errorf("test%d", calls)
+	})
+	errs, ok := err.(*Errors)
+	if !ok || errs.Len() != 3 || !IsPanicError(errs.First()) || errs.Last().Error() != "test3" {
+		t.Fatalf("RetryCall(): %v", err)
+	}
+	if d := time.Since(start); d < time.Millisecond*20 {
+		t.Fatalf("RetryCall(): %s", d)
+	}
+
+	calls = 0
+	if err := RetryCall(3, 0, func() error {
+		calls++
+		return nil
+	}); err != nil || calls != 1 {
+		t.Fatalf("RetryCall(): %v %d", err, calls)
+	}
+}
+
+func TestRetryPolicy_BackoffFunc(t *testing.T) {
+	backoff := RetryPolicy{Backoff: time.Second, Multiplier: 2, MaxBackoff: 5 * time.Second}.BackoffFunc()
+	var got []time.Duration
+	for attempt := 1; attempt <= 5; attempt++ {
+		got = append(got, backoff(attempt))
+	}
+	if s := fmt.Sprint(got); s != "[1s 2s 4s 5s 5s]" {
+		t.Fatalf("RetryPolicy.BackoffFunc(): %s", s)
+	}
+
+	backoff = RetryPolicy{Backoff: time.Second}.BackoffFunc()
+	if d := backoff(10); d != time.Second {
+		t.Fatalf("RetryPolicy.BackoffFunc(): %s", d)
+	}
+
+	// The growing backoff does not overflow without the MaxBackoff.
+	backoff = RetryPolicy{Backoff: time.Second, Multiplier: 2}.BackoffFunc()
+	last := backoff(1)
+	for _, attempt := range []int{2, 35, 64, 1000, math.MaxInt32} {
+		d := backoff(attempt)
+		if d < last {
+			t.Fatalf("RetryPolicy.BackoffFunc(): %d %s", attempt, d)
+		}
+		last = d
+	}
+	if last != math.MaxInt64 {
+		t.Fatalf("RetryPolicy.BackoffFunc(): %s", last)
+	}
+	backoff = RetryPolicy{Backoff: time.Second, Multiplier: 2, MaxBackoff: time.Minute}.BackoffFunc()
+	if d := backoff(math.MaxInt32); d != time.Minute {
+		t.Fatalf("RetryPolicy.BackoffFunc(): %s", d)
+	}
+}
@@ -15,71 +15,385 @@
 package runner
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// ErrExited returns when running a task in an exited Runner.
-var ErrExited = errors.New("runner: exited")
+var (
+	// ErrExited returns when running a task in an exited Runner.
+	ErrExited = errors.New("runner: exited")
+
+	// ErrReadyTimeout returns when the tasks of the Runner are not ready in time.
+	ErrReadyTimeout = errors.New("runner: ready timeout")
+
+	// ErrShutdownTimeout returns when the shutdown of a task exceeds the shutdown
+	// timeout of the task, see the TimeoutTask interface.
+	ErrShutdownTimeout = errors.New("runner: shutdown timeout")
+
+	// ErrForcedExit returns when the exit of the Runner exceeds the time limit
+	// set by the WithForceExitAfter option.
+	ErrForcedExit = errors.New("runner: forced exit")
+
+	// ErrNotExited returns when resetting a Runner that has not exited.
+	ErrNotExited = errors.New("runner: not exited")
+)
 
 // Runner defines the task runner.
 // The task runner is used to manage the operation and shutdown of multiple
 // independent subtasks of an application.
 type Runner interface {
 	// Run method executes the given task instance synchronously.
-	// If the runner has exited, the ErrExited error will be returned.
+	// If the runner has exited or is exiting, the ErrExited error will be returned.
+	// If the given task is an optional task and fails to execute, the error is
+	// only logged, and nil is returned.
 	Run(Task) error
 
+	// RunContext method is like Run, but the given context is passed to the task that
+	// implements the ExecuteContextTask interface, so its execution can be aborted.
+	// If the given context is done, the task is not executed and the context error
+	// is returned.
+	RunContext(context.Context, Task) error
+
 	// MustRun method executes the given task instance synchronously.
 	// If the task execution returns a non nil error, panic immediately.
 	MustRun(Task) Runner
 
+	// RunIf method executes the given task instance synchronously only if the given
+	// condition is true, otherwise the task is ignored and nil is returned.
+	RunIf(bool, Task) error
+
+	// MustRunIf method is like MustRun, but the given task is executed only if the
+	// given condition is true.
+	MustRunIf(bool, Task) Runner
+
+	// RunUnless method executes the given task instance synchronously only if the
+	// given condition is false, otherwise the task is ignored and nil is returned.
+	RunUnless(bool, Task) error
+
+	// RunBackground method executes the task created by the NewBackgroundTask function
+	// with the given functions synchronously, so it returns after the start function
+	// signals the readiness, or returns the error of the start function if it returns
+	// before the readiness. The stop function is called when the runner exits.
+	RunBackground(start func(ready chan<- struct{}) error, stop func() error) error
+
+	// RunSupervised method registers the given task and executes it asynchronously
+	// under supervision. If the execution fails (returns an error or panics), the task
+	// is restarted according to the given policy, until it returns nil, the policy gives
+	// up, or the task is shut down. The task is registered immediately, so it is always
+	// shut down when the runner exits. If the runner has exited, ErrExited is returned.
+	RunSupervised(Task, RestartPolicy) error
+
+	// RunDeferred method executes the given task instance asynchronously.
+	// If the task execution fails, the task is not registered, and the error is
+	// collected and returned by the Wait and WaitBy methods. The exit of the runner
+	// waits for all deferred task executions to complete, unless the exit context
+	// is done or the force exit time limit is exceeded first, then the executions
+	// still in progress are abandoned and reported in the exit error, and each of
+	// them is shut down as soon as it completes successfully.
+	// If the runner has exited, the ErrExited error is returned.
+	RunDeferred(Task) error
+
+	// WaitReadyTimeout method blocks the current coroutine until all the tasks of the
+	// runner are ready, which means that all the deferred task executions have completed.
+	// If the tasks are not ready within the given duration, an error wrapping the
+	// ErrReadyTimeout error is returned, it contains the names of the tasks not ready.
+	WaitReadyTimeout(time.Duration) error
+
 	// Wait method blocks the current coroutine until the runner exits.
 	// When the exit signal is received or the exit method is called,
 	// the blocking state of the method is released.
 	Wait() error
 
+	// WaitEx method is like Wait, but it also reports the reason why the blocking
+	// state is released. If the exit signal of the operating system is captured,
+	// ReasonSignal is returned with the exit error. If the Exit method is called
+	// explicitly, ReasonExitCall is returned.
+	WaitEx() (ExitReason, error)
+
 	// WaitBy method blocks the current coroutine until the runner exits.
 	// When a given channel is closed or the exit method is called,
 	// the blocking state of the method is released.
 	WaitBy(<-chan struct{}) error
 
+	// WaitSignals method blocks the current coroutine until one of the given operating
+	// system signals is captured or the exit method is called. If a signal is captured,
+	// the runner is exited by this method, and the signal is returned with the exit error.
+	// If the exit method is called, a nil signal is returned. Unlike the Wait method,
+	// the default exit signals are not listened unless they are given.
+	WaitSignals(...os.Signal) (os.Signal, error)
+
+	// WaitFor method is like WaitBy, but it accepts a waiter, such as the waiters
+	// combined by the WaitAny function. When the given waiter is closed or the exit
+	// method is called, the blocking state of the method is released.
+	WaitFor(Waiter) error
+
+	// WaitByReason method is like WaitBy, but it also reports the reason why the
+	// blocking state is released. If the given channel is closed, the runner is exited
+	// by this method and ReasonChannel is returned with the exit error. If the Exit
+	// method is called explicitly, ReasonExplicit is returned, or ReasonRestart if the
+	// GracefulRestart method is called.
+	WaitByReason(<-chan struct{}) (ExitReason, error)
+
+	// RunUntil method blocks the current coroutine until the given context is done,
+	// the exit signal of the operating system is captured, or the Exit method is called.
+	// Unless the Exit method is called, the runner then exits through the ExitContext
+	// method with a grace context, and the exit error is returned. The deadline of the
+	// grace context is the grace period set by the WithGracePeriod option; without the
+	// option, it is the deadline of the given context if the exit signal is captured
+	// before the deadline, otherwise the grace context has no deadline.
+	RunUntil(context.Context) error
+
+	// OnBeforeExit method registers a hook that is called once when the current runner
+	// starts to exit, before the tasks are shut down. The hooks are called in the order
+	// of registration with panic protection, the panic is only logged.
+	// The hooks registered after the runner exits are never called. The hooks are called
+	// without the lock of the runner, so they can call the methods of the runner, except
+	// the exit methods, and the run methods return ErrExited while the runner is exiting.
+	OnBeforeExit(func())
+
+	// OnAfterExit method registers a hook that is called once with the aggregated
+	// shutdown error after the tasks of the current runner are shut down. The hooks
+	// are called in the order of registration with panic protection, the panic is
+	// only logged. The hooks registered after the runner exits are never called.
+	// Like the OnBeforeExit hooks, they are called without the lock of the runner.
+	OnAfterExit(func(error))
+
+	// OnGracefulRestart method registers a hook that is called when the GracefulRestart
+	// method is called, before the runner exits. The hooks are called in the order of
+	// registration, and if any hook fails, the remaining hooks are not called and the
+	// runner does not exit.
+	OnGracefulRestart(func() error)
+
+	// GracefulRestart method calls the graceful restart hooks, and then exits the
+	// current runner, so the waiting methods report the ReasonRestart reason instead
+	// of ReasonExplicit. It is designed for the zero-downtime restart, for example, a
+	// hook starts the new process that inherits the listening sockets, and then the
+	// old process drains its tasks. Passing the file descriptors is the responsibility
+	// of the hooks, the runner only orchestrates the order.
+	// If any hook fails or panics, its error is returned and the runner keeps running.
+	// If the runner has exited, ErrExited is returned.
+	GracefulRestart() error
+
 	// Exit method exits the current runner.
 	Exit() error
 
+	// ExitContext method exits the current runner with the given context.
+	// If any task implements the NamedTask interface, the shutdown error of each task
+	// is annotated with the name of the task, or the index of the task if it is not
+	// named, such as "cache: err2; #0: err1".
+	// If the given context has a deadline, each task that implements the ContextTask
+	// interface receives a context whose deadline is the remaining shutdown budget.
+	// If the given context is done before all the tasks are shut down, the exit is
+	// aborted, the tasks that have not been shut down are abandoned, and the returned
+	// error wraps the context error and names the task that did not finish.
+	ExitContext(context.Context) error
+
+	// ExitWithTimeout method exits the current runner within the given duration.
+	// The duration is distributed to the tasks in proportion to their shutdown weights
+	// (see the WeightedTask interface), the tasks without a weight have the weight 1,
+	// so the duration is split evenly if no weight is declared. The shutdown of each
+	// task is limited by its share, the task that exceeds its share is abandoned with
+	// the ErrShutdownTimeout error. The whole exit is also bounded by the duration,
+	// as if the ExitContext method is called with a context with the timeout.
+	ExitWithTimeout(time.Duration) error
+
+	// ExitSeparated method is like Exit, but the shutdown errors are partitioned into
+	// the ordinary errors and the panics (the errors that wrap a *PanicError), so they
+	// can be handled differently, for example, a panic is reported as a bug.
+	// An error list is nil if there is no error of its kind.
+	ExitSeparated() (errs *Errors, panics *Errors)
+
 	// Exited method determines whether the current runner has exited.
 	Exited() bool
+
+	// State method returns the snapshot of the lifecycle state of the current runner,
+	// which includes the number of the live tasks and the exit time. Unlike the other
+	// methods, it never waits for the exit in progress.
+	State() RunnerState
+
+	// Done method returns a channel that is closed when the current runner exits.
+	Done() <-chan struct{}
+
+	// Reset method resets the exited runner, so it can run tasks again, for example,
+	// in tests or supervised restarts. The options, hooks and event stream are kept,
+	// and the errors of the failed deferred task executions are cleared. If the runner
+	// has not exited, the ErrNotExited error is returned.
+	// This method replaces the exit channel, so the channels returned by the Done method
+	// before the reset belong to the previous run and remain closed. It is safe to call
+	// this method concurrently with the other methods of the runner.
+	Reset() error
+
+	// Tasks method returns a snapshot of the tasks registered in the current runner in
+	// the order of registration. After the runner exits, an empty slice is returned.
+	Tasks() []Task
+
+	// Events method returns the lifecycle event stream of the current runner.
+	// The stream is a buffered channel, when it is full, the oldest event is
+	// dropped, so consumers must keep draining it. Events are only recorded
+	// after this method is called for the first time.
+	Events() <-chan Event
+
+	// EventsStop method closes the lifecycle event stream of the current runner.
+	// It is usually called after the runner exits. This method is idempotent.
+	EventsStop()
+
+	// Subscribe method registers an observer of the lifecycle events of the current
+	// runner. The observers are called in the order of registration for each event,
+	// never concurrently, so they receive the events in order, and they should return
+	// quickly. The panic of an observer is only logged. The observers are called after
+	// the lock of the runner is released, so they can call the methods of the runner,
+	// except the exit methods, which wait for the exit in progress.
+	Subscribe(func(Event))
+
+	// History method returns the last lifecycle events retained by the current runner,
+	// from the oldest to the newest. The number of the retained events is set by the
+	// WithEventHistory option, and nil is returned if the option is not set.
+	History() []Event
+
+	// Config method returns the snapshot of the effective options of the current
+	// runner, which is useful for debugging.
+	Config() RunnerConfig
 }
 
 // New creates and returns a new instance of the Runner.
-func New() Runner {
-	return &runner{chanExit: make(chan struct{})}
+func New(options ...Option) Runner {
+	r := &runner{now: time.Now, logger: stdLogger{}}
+	r.chanExit.Store(make(chan struct{}))
+	r.cond = sync.NewCond(&r.mutex)
+	for _, option := range options {
+		option(r)
+	}
+	return r
 }
 
 // The runner type is an implementation of the built-in Runner.
 type runner struct {
 	mutex    sync.Mutex
 	tasks    []Task
-	chanExit chan struct{}
+	chanExit atomic.Value
 	onceExit sync.Once
+	now      func() time.Time
+	logger   Logger
+
+	// The exits are serialized by the exit mutex, the mutex of the runner is released
+	// while the exit hooks, the observers and the shutdowns are called, and the run
+	// methods return ErrExited while the exiting flag is set.
+	exitMutex sync.Mutex
+	exiting   bool
+
+	// The lifecycle state is guarded by its own mutex, so it can be read while
+	// the runner is exiting.
+	stateMutex sync.Mutex
+	state      RunnerState
+
+	// The deferred task executions in progress, the cond is signaled when one
+	// of them completes, and the errors of the failed executions.
+	cond         *sync.Cond
+	pending      []*pendingTask
+	deferredErrs Errors
+
+	logShutdownPanic bool
+	forceExitAfter   time.Duration
+	onForceExit      func([]Task)
+	phases           []string
+
+	dedupeShutdownErrors bool
+	gracePeriod          time.Duration
+	exitProgress         func(done, total int, current Task)
+	testMode             bool
+	hardShutdownDeadline time.Duration
+	errorSeparator       string
+	taskDecorators       []func(Task) Task
+
+	beforeExitHooks []func()
+	restartHooks    []func() error
+	restarting      bool
+	afterExitHooks  []func(error)
+
+	eventMutex   sync.Mutex
+	events       chan Event
+	eventStopped bool
+	observers    []func(Event)
+	// The events queued for the observers, and whether a coroutine is notifying them.
+	queued    []Event
+	notifying bool
+
+	// The ring buffer of the last lifecycle events, the historyNext is the index
+	// of the oldest event when the buffer is full.
+	historySize int
+	history     []Event
+	historyNext int
 }
 
 // Run method executes the given task instance synchronously.
 // If the runner has exited, the ErrExited error will be returned.
+// If the given task is an optional task and fails to execute, the error is
+// only logged, and nil is returned.
 func (r *runner) Run(t Task) error {
+	return r.RunContext(context.Background(), t)
+}
+
+// RunContext method is like Run, but the given context is passed to the task that
+// implements the ExecuteContextTask interface, so its execution can be aborted.
+// If the given context is done, the task is not executed and the context error
+// is returned.
+func (r *runner) RunContext(ctx context.Context, t Task) error {
+	defer r.flushEvents()
 	r.mutex.Lock()
 	defer r.mutex.Unlock()
-	if r.Exited() {
+	return r.runLocked(ctx, t)
+}
+
+// Executes the given task synchronously and registers it, the mutex must be held,
+// and the events must be flushed after the mutex is released.
+func (r *runner) runLocked(ctx context.Context, t Task) error {
+	if r.closed() {
 		return ErrExited
 	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
 
-	if err := SafeCall(t.Execute); err != nil {
+	t = r.decorate(t)
+	// The mutex is held, so the runner-aware task registers its sub-tasks through
+	// the nested runner, which does not lock again.
+	if err := executeTask(ctx, t, &nestedRunner{runner: r}); err != nil {
+		r.emitLocked(EventTaskRunError, t, err)
+		if isOptionalTask(t) {
+			r.logger.Printf("runner: optional task %s execute: %s", taskName(t), err)
+			return nil
+		}
 		return err
 	}
-	r.tasks = append(r.tasks, t)
+	r.register(t)
 	return nil
 }
 
+// The nestedRunner type is the TaskRunner given to the runner-aware task executed
+// while the mutex of the runner is held, it registers the sub-tasks without locking
+// again. It does not embed the runner, so the methods that take the lock can not be
+// reached through it.
+type nestedRunner struct {
+	runner *runner
+}
+
+// Run method executes the given task instance synchronously.
+func (r *nestedRunner) Run(t Task) error {
+	return r.RunContext(context.Background(), t)
+}
+
+// RunContext method is like Run, but the given context is passed to the task.
+func (r *nestedRunner) RunContext(ctx context.Context, t Task) error {
+	return r.runner.runLocked(ctx, t)
+}
+
 // MustRun method executes the given task instance synchronously.
 // If the task execution returns a non nil error, panic immediately.
 func (r *runner) MustRun(t Task) Runner {
@@ -87,6 +401,191 @@ func (r *runner) MustRun(t Task) Runner {
 	return r
 }
 
+// RunBackground method executes the task created by the NewBackgroundTask function
+// with the given functions synchronously.
+func (r *runner) RunBackground(start func(ready chan<- struct{}) error, stop func() error) error {
+	return r.Run(NewBackgroundTask(start, stop))
+}
+
+// RunIf method executes the given task instance synchronously only if the given
+// condition is true, otherwise the task is ignored and nil is returned.
+func (r *runner) RunIf(cond bool, t Task) error {
+	if !cond {
+		return nil
+	}
+	return r.Run(t)
+}
+
+// MustRunIf method is like MustRun, but the given task is executed only if the
+// given condition is true.
+func (r *runner) MustRunIf(cond bool, t Task) Runner {
+	if cond {
+		r.MustRun(t)
+	}
+	return r
+}
+
+// RunUnless method executes the given task instance synchronously only if the
+// given condition is false, otherwise the task is ignored and nil is returned.
+func (r *runner) RunUnless(cond bool, t Task) error {
+	return r.RunIf(!cond, t)
+}
+
+// RunDeferred method executes the given task instance asynchronously.
+// If the task execution fails, the task is not registered, and the error is
+// collected and returned by the Wait and WaitBy methods. The exit of the runner
+// waits for all deferred task executions to complete, unless the exit is aborted.
+// If the runner has exited, the ErrExited error is returned.
+func (r *runner) RunDeferred(t Task) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed() {
+		return ErrExited
+	}
+
+	p := &pendingTask{task: r.decorate(t)}
+	r.pending = append(r.pending, p)
+	go r.runDeferred(p)
+	return nil
+}
+
+// Returns the given task wrapped by the task decorators of the runner in order,
+// the first decorator wraps the given task, and the last one wraps the result.
+// The declarative interfaces of the given task are looked up through the result,
+// see the decoratedTask type.
+func (r *runner) decorate(t Task) Task {
+	if len(r.taskDecorators) == 0 {
+		return t
+	}
+	d := t
+	for _, decorator := range r.taskDecorators {
+		d = decorator(d)
+	}
+	return &decoratedTask{task: d, origin: t}
+}
+
+// The decoratedTask type is the result of the task decorators registered in the
+// runner, which keeps the original task. The declarative interfaces (NamedTask,
+// OptionalTask, PhasedTask, TimeoutTask and WeightedTask) are looked up in the
+// decorated task first, and then in the original task, so the decorators do not need
+// to forward them. The behavioral interfaces (ContextTask, ExecuteContextTask and
+// RunnerAwareTask) of the decorated task are used, the decorator must forward them
+// if the original task should receive the contexts or the runner. The runner exposes
+// the decorated task instead of this type, see the exposedTask function.
+type decoratedTask struct {
+	task   Task
+	origin Task
+}
+
+// Returns the decorated task and the original task.
+func (t *decoratedTask) unwrap() []Task {
+	return []Task{t.task, t.origin}
+}
+
+// Execute method executes the decorated task.
+func (t *decoratedTask) Execute() error {
+	return t.task.Execute()
+}
+
+// Executes the decorated task with the given context and runner.
+func (t *decoratedTask) executeWith(ctx context.Context, r TaskRunner) error {
+	return callExecute(ctx, t.task, r)
+}
+
+// Shutdown method shuts down the decorated task.
+func (t *decoratedTask) Shutdown() error {
+	return t.task.Shutdown()
+}
+
+// ShutdownContext method shuts down the decorated task with the given context.
+func (t *decoratedTask) ShutdownContext(ctx context.Context) error {
+	return callShutdown(ctx, t.task)
+}
+
+// Returns the task exposed to the users of the runner for the given registered task,
+// which is the decorated task if the given task is decorated.
+func exposedTask(t Task) Task {
+	if d, ok := t.(*decoratedTask); ok {
+		return d.task
+	}
+	return t
+}
+
+// The pendingTask type represents a deferred task execution in progress.
+type pendingTask struct {
+	task Task
+	// The execution is abandoned by the exit of the runner, the mutex must be held.
+	abandoned bool
+}
+
+// Execute the given task and register it if the execution succeeds.
+func (r *runner) runDeferred(p *pendingTask) {
+	t := p.task
+	err := executeTask(context.Background(), t, r)
+
+	defer r.flushEvents()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if p.abandoned {
+		// The exit has reported the abandoned execution, the task is shut down
+		// immediately, since it will never be shut down by the runner.
+		if err == nil {
+			err = SafeCall(t.Shutdown)
+		}
+		if err != nil {
+			r.logger.Printf("runner: abandoned deferred task %s: %s", taskName(t), err)
+		}
+		return
+	}
+	if err != nil {
+		r.emitLocked(EventTaskRunError, t, err)
+		if isOptionalTask(t) {
+			r.logger.Printf("runner: optional task %s execute: %s", taskName(t), err)
+		} else {
+			r.deferredErrs.Add(err)
+		}
+	} else {
+		r.register(t)
+	}
+	for i := range r.pending {
+		if r.pending[i] == p {
+			r.pending = append(r.pending[:i], r.pending[i+1:]...)
+			break
+		}
+	}
+	r.cond.Broadcast()
+}
+
+// WaitReadyTimeout method blocks the current coroutine until all the tasks of the
+// runner are ready, which means that all the deferred task executions have completed.
+// If the tasks are not ready within the given duration, an error wrapping the
+// ErrReadyTimeout error is returned, it contains the names of the tasks not ready.
+func (r *runner) WaitReadyTimeout(d time.Duration) error {
+	var timeout bool
+	timer := time.AfterFunc(d, func() {
+		r.mutex.Lock()
+		timeout = true
+		r.cond.Broadcast()
+		r.mutex.Unlock()
+	})
+	defer timer.Stop()
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for len(r.pending) > 0 && !timeout {
+		r.cond.Wait()
+	}
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	names := make([]string, 0, len(r.pending))
+	for i := range r.pending {
+		names = append(names, taskName(r.pending[i].task))
+	}
+	return fmt.Errorf("%w: %s", ErrReadyTimeout, strings.Join(names, ", "))
+}
+
 // Wait method blocks the current coroutine until the runner exits.
 // When the exit signal is received or the exit method is called,
 // the blocking state of the method is released.
@@ -94,60 +593,727 @@ func (r *runner) Wait() error {
 	return r.WaitBy(GetSystemExitChan())
 }
 
+// WaitEx method is like Wait, but it also reports the reason why the blocking
+// state is released.
+func (r *runner) WaitEx() (ExitReason, error) {
+	return r.waitEx(GetSystemExitChan())
+}
+
+// Like WaitByReason, but the given channel is the system exit signal channel.
+func (r *runner) waitEx(c <-chan struct{}) (ExitReason, error) {
+	reason, err := r.WaitByReason(c)
+	if reason == ReasonChannel {
+		reason = ReasonSignal
+	}
+	return reason, err
+}
+
 // WaitBy method blocks the current coroutine until the runner exits.
 // When a given channel is closed or the exit method is called,
 // the blocking state of the method is released.
 func (r *runner) WaitBy(c <-chan struct{}) error {
+	_, err := r.WaitByReason(c)
+	return err
+}
+
+// WaitSignals method blocks the current coroutine until one of the given operating
+// system signals is captured or the exit method is called.
+func (r *runner) WaitSignals(sigs ...os.Signal) (os.Signal, error) {
+	if r.Exited() {
+		return nil, r.withDeferredErrors(nil)
+	}
+	// In the test mode, the runner exits immediately without a signal.
+	if r.testMode {
+		return nil, r.withDeferredErrors(r.Exit())
+	}
+
+	c := make(chan os.Signal, 1)
+	// Without the given signals, the Notify function relays all the signals.
+	if len(sigs) > 0 {
+		signal.Notify(c, sigs...)
+		defer signal.Stop(c)
+	}
+	select {
+	case sig := <-c:
+		return sig, r.withDeferredErrors(r.Exit())
+	case <-r.exitChan():
+		return nil, r.withDeferredErrors(nil)
+	}
+}
+
+// WaitFor method is like WaitBy, but it accepts a waiter, such as the waiters
+// combined by the WaitAny function. When the given waiter is closed or the exit
+// method is called, the blocking state of the method is released.
+func (r *runner) WaitFor(w Waiter) error {
+	return r.WaitBy(w.Channel())
+}
+
+// WaitByReason method is like WaitBy, but it also reports the reason why the
+// blocking state is released. If the given channel is closed, the runner is exited
+// by this method and ReasonChannel is returned with the exit error. If the Exit
+// method is called explicitly, ReasonExplicit is returned, or ReasonRestart if the
+// GracefulRestart method is called.
+func (r *runner) WaitByReason(c <-chan struct{}) (ExitReason, error) {
+	// If the runner has exited, the given channel is ignored.
+	if r.Exited() {
+		return r.exitReason(), r.withDeferredErrors(nil)
+	}
+
+	// In the test mode, the runner exits as if the given channel is closed.
+	if r.testMode {
+		return ReasonChannel, r.withDeferredErrors(r.Exit())
+	}
+
 	select {
 	case <-c:
-		return r.Exit()
-	case <-r.chanExit:
+		return ReasonChannel, r.withDeferredErrors(r.Exit())
+	case <-r.exitChan():
 		// In this case, because the Exit method is called, do nothing!
+		return r.exitReason(), r.withDeferredErrors(nil)
+	}
+}
+
+// RunUntil method blocks the current coroutine until the given context is done,
+// the exit signal of the operating system is captured, or the Exit method is called.
+func (r *runner) RunUntil(ctx context.Context) error {
+	return r.runUntil(ctx, GetSystemExitChan())
+}
+
+// Blocks until the given context is done, the given channel is closed, or the Exit
+// method is called, and exits the runner with the grace context.
+func (r *runner) runUntil(ctx context.Context, c <-chan struct{}) error {
+	if r.Exited() {
+		return r.withDeferredErrors(nil)
+	}
+
+	// In the test mode, the runner exits immediately.
+	if !r.testMode {
+		select {
+		case <-ctx.Done():
+		case <-c:
+		case <-r.exitChan():
+			return r.withDeferredErrors(nil)
+		}
+	}
+
+	grace, cancel := r.graceContext(ctx)
+	defer cancel()
+	return r.withDeferredErrors(r.ExitContext(grace))
+}
+
+// Returns the grace context of the RunUntil method, the given context may be done.
+func (r *runner) graceContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if r.gracePeriod > 0 {
+		return context.WithTimeout(context.Background(), r.gracePeriod)
+	}
+	if deadline, ok := ctx.Deadline(); ok && ctx.Err() == nil {
+		return context.WithDeadline(context.Background(), deadline)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// Combine the errors of the failed deferred task executions with the given error.
+func (r *runner) withDeferredErrors(err error) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if r.deferredErrs.Len() == 0 {
+		return err
+	}
+	errs := &Errors{sep: r.errorSeparator}
+	errs.Add(&r.deferredErrs)
+	errs.Add(err)
+	return errs.result()
+}
+
+// OnBeforeExit method registers a hook that is called once when the current runner
+// starts to exit, before the tasks are shut down.
+func (r *runner) OnBeforeExit(hook func()) {
+	r.mutex.Lock()
+	r.beforeExitHooks = append(r.beforeExitHooks, hook)
+	r.mutex.Unlock()
+}
+
+// OnAfterExit method registers a hook that is called once with the aggregated
+// shutdown error after the tasks of the current runner are shut down.
+func (r *runner) OnAfterExit(hook func(error)) {
+	r.mutex.Lock()
+	r.afterExitHooks = append(r.afterExitHooks, hook)
+	r.mutex.Unlock()
+}
+
+// OnGracefulRestart method registers a hook that is called when the GracefulRestart
+// method is called, before the runner exits.
+func (r *runner) OnGracefulRestart(hook func() error) {
+	r.mutex.Lock()
+	r.restartHooks = append(r.restartHooks, hook)
+	r.mutex.Unlock()
+}
+
+// GracefulRestart method calls the graceful restart hooks, and then exits the
+// current runner with the ReasonRestart reason.
+func (r *runner) GracefulRestart() error {
+	r.mutex.Lock()
+	if r.Exited() {
+		r.mutex.Unlock()
+		return ErrExited
+	}
+	hooks := append([]func() error(nil), r.restartHooks...)
+	r.mutex.Unlock()
+
+	// The hooks are called without the lock, so they can use the runner.
+	for _, hook := range hooks {
+		if err := SafeCall(hook); err != nil {
+			return err
+		}
+	}
+
+	r.mutex.Lock()
+	// The runner may have been exited while the hooks were being called.
+	if r.Exited() {
+		r.mutex.Unlock()
 		return nil
 	}
+	r.restarting = true
+	r.mutex.Unlock()
+	return r.Exit()
+}
+
+// Returns the reason of the exit that is not caused by the waiting methods.
+// The runner must have exited.
+func (r *runner) exitReason() ExitReason {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.restarting {
+		return ReasonRestart
+	}
+	return ReasonExplicit
+}
+
+// Call the given exit hook with panic protection, the panic is only logged.
+func (r *runner) callExitHook(kind string, hook func()) {
+	if err := SafeCall(func() error {
+		hook()
+		return nil
+	}); err != nil {
+		r.logger.Printf("runner: %s exit hook panic: %s", kind, err)
+	}
 }
 
 // Exit method exits the current runner.
 func (r *runner) Exit() error {
+	return r.ExitContext(context.Background())
+}
+
+// ExitContext method exits the current runner with the given context.
+// If the given context has a deadline, each task that implements the ContextTask
+// interface receives a context whose deadline is the remaining shutdown budget.
+// If the given context is done before all the tasks are shut down, the exit is
+// aborted, the tasks that have not been shut down are abandoned, and the returned
+// error wraps the context error and names the task that did not finish.
+func (r *runner) ExitContext(ctx context.Context) error {
+	return r.exit(ctx, 0)
+}
+
+// ExitWithTimeout method exits the current runner within the given duration.
+func (r *runner) ExitWithTimeout(d time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return r.exit(ctx, d)
+}
+
+// ExitSeparated method is like Exit, but the shutdown errors are partitioned into
+// the ordinary errors and the panics.
+func (r *runner) ExitSeparated() (errs *Errors, panics *Errors) {
+	all := new(Errors)
+	all.Add(r.Exit())
+	all.Range(func(_ int, err error) bool {
+		var pe *PanicError
+		if errors.As(err, &pe) {
+			if panics == nil {
+				panics = &Errors{sep: r.errorSeparator}
+			}
+			panics.Add(err)
+		} else {
+			if errs == nil {
+				errs = &Errors{sep: r.errorSeparator}
+			}
+			errs.Add(err)
+		}
+		return true
+	})
+	return
+}
+
+// Exits the current runner with the given context, if the given budget is positive,
+// it is distributed to the tasks in proportion to their shutdown weights.
+func (r *runner) exit(ctx context.Context, budget time.Duration) error {
 	if r.Exited() {
 		return nil
 	}
 
+	r.exitMutex.Lock()
+	defer r.exitMutex.Unlock()
+
+	r.mutex.Lock()
+	// The force exit time limit covers the whole exit, including the waiting for
+	// the deferred task executions.
+	var forceAt time.Time
+	if r.forceExitAfter > 0 {
+		forceAt = time.Now().Add(r.forceExitAfter)
+	}
+	abandoned := r.waitPending(ctx, forceAt)
+	// The runner may have been exited while we were waiting for the lock.
+	if r.Exited() {
+		r.mutex.Unlock()
+		return nil
+	}
+	r.exiting = true
+	beforeExitHooks := append([]func(){}, r.beforeExitHooks...)
+	r.mutex.Unlock()
+
+	// The hooks, the observers and the shutdowns are called without the lock, so
+	// they can call the methods of the runner, the run methods return ErrExited.
+	r.setState(func(s *RunnerState) { s.State = StateExiting })
+	r.emit(EventExitStarted, nil, nil)
+	for _, hook := range beforeExitHooks {
+		r.callExitHook("before", func() { hook() })
+	}
+
+	r.mutex.Lock()
+	tasks := r.tasks
+	r.tasks = nil
+	r.mutex.Unlock()
+
+	err := r.shutdownAll(ctx, budget, tasks, forceAt)
+	if abandoned != nil {
+		abandoned.Add(err)
+		err = r.shutdownResult(abandoned)
+	}
+
 	r.mutex.Lock()
-	defer func() {
-		// Make sure to unblock the Wait method.
-		r.onceExit.Do(r.closeExitChan)
+	afterExitHooks := append([]func(error){}, r.afterExitHooks...)
+	r.mutex.Unlock()
+	for _, hook := range afterExitHooks {
+		r.callExitHook("after", func() { hook(err) })
+	}
+	r.emit(EventExitFinished, nil, err)
+	exitedAt := r.now()
+	r.setState(func(s *RunnerState) {
+		s.State, s.Tasks, s.ExitedAt = StateExited, 0, exitedAt
+	})
+
+	r.mutex.Lock()
+	r.exiting = false
+	// Make sure to unblock the Wait method.
+	r.onceExit.Do(r.closeExitChan)
+	r.mutex.Unlock()
+	return err
+}
+
+// Determines whether the current runner has exited or is exiting, so it does not
+// accept tasks anymore. The mutex must be held.
+func (r *runner) closed() bool {
+	return r.exiting || r.Exited()
+}
+
+// Waits for all deferred task executions to complete, the mutex must be held, and it
+// is released while waiting, so the deferred tasks can be registered. If the given
+// context is done or the given force exit time is reached first, the executions in
+// progress are abandoned, and the returned error list reports them.
+func (r *runner) waitPending(ctx context.Context, forceAt time.Time) *Errors {
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	var force <-chan time.Time
+	if !forceAt.IsZero() {
+		timer := time.NewTimer(time.Until(forceAt))
+		defer timer.Stop()
+		force = timer.C
+	}
+	var reason error
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		var err error
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			err = ctx.Err()
+		case <-force:
+			err = ErrForcedExit
+		}
+		r.mutex.Lock()
+		if reason == nil {
+			reason = err
+		}
+		r.cond.Broadcast()
 		r.mutex.Unlock()
 	}()
+
+	for len(r.pending) > 0 && reason == nil {
+		r.cond.Wait()
+	}
+	if len(r.pending) == 0 {
+		return nil
+	}
+
+	errs := new(Errors)
+	for _, p := range r.pending {
+		p.abandoned = true
+		errs.Add(fmt.Errorf("runner: deferred task %s did not finish execution: %w", taskName(p.task), reason))
+	}
+	r.pending = nil
+	r.cond.Broadcast()
+	return errs
+}
+
+// Shut down the given tasks of the current runner in the shutdown order.
+// If the given force exit time is not zero, the shutdown is abandoned when it is reached.
+func (r *runner) shutdownAll(ctx context.Context, budget time.Duration, tasks []Task, forceAt time.Time) error {
 	// In this case, we don't care about the state of the runner, just
 	// make sure that all tasks in the current runner are shut down.
-	if len(r.tasks) == 0 {
+	if len(tasks) == 0 {
 		return nil
 	}
 
+	order := r.shutdownOrder(tasks)
+	shares := shutdownShares(tasks, budget)
+	// The context that can be done, or the force exit time limit, requires us
+	// to stop waiting for the hung task.
+	if !forceAt.IsZero() || ctx.Done() != nil {
+		return r.shutdownAllAsync(ctx, tasks, order, shares, forceAt)
+	}
+
 	err := new(Errors)
-	for i := len(r.tasks) - 1; i >= 0; i-- {
-		err.Add(SafeCall(r.tasks[i].Shutdown))
+	named := hasNamedTask(tasks)
+	for k, i := range order {
+		addShutdownError(err, named, i, tasks[i], r.shutdownStep(ctx, k, len(order), i, tasks[i], shares[i], r.emit))
 	}
-	r.tasks = r.tasks[:0]
+	return r.shutdownResult(err)
+}
 
-	if err.Len() > 1 {
-		return err
+// Returns the shares of the given shutdown budget of the given tasks, indexed by the
+// task index. The budget is distributed in proportion to the shutdown weights of the
+// tasks, the tasks that do not implement the WeightedTask interface, or have a weight
+// less than 1, have the weight 1, so the budget is split evenly if no weight is declared.
+// For example, a 30s budget for two tasks with the weights 5 and 1 gives 25s and 5s.
+// If the given budget is not positive, all the shares are zero, which means no limit.
+func shutdownShares(tasks []Task, budget time.Duration) []time.Duration {
+	shares := make([]time.Duration, len(tasks))
+	if budget <= 0 {
+		return shares
+	}
+	weights := make([]int64, len(tasks))
+	var sum int64
+	for i := range tasks {
+		weights[i] = 1
+		if wt, ok := asWeightedTask(tasks[i]); ok && wt.ShutdownWeight() > 1 {
+			weights[i] = int64(wt.ShutdownWeight())
+		}
+		sum += weights[i]
+	}
+	for i := range tasks {
+		shares[i] = time.Duration(int64(budget) / sum * weights[i])
+	}
+	return shares
+}
+
+// Determines whether any of the given tasks is named.
+func hasNamedTask(tasks []Task) bool {
+	for i := range tasks {
+		if _, ok := asNamedTask(tasks[i]); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Adds the shutdown error of the given task with the index i to the given error list.
+// If any task is named, the error is annotated with the name of the task, or the
+// index of the task if it is not named, such as "cache: err2; #0: err1".
+func addShutdownError(errs *Errors, named bool, i int, t Task, err error) {
+	if !named {
+		errs.Add(err)
+	} else if n, ok := asNamedTask(t); ok {
+		errs.AddContext(n.Name(), err)
+	} else {
+		errs.AddContext(fmt.Sprintf("#%d", i), err)
+	}
+}
+
+// Returns the given shutdown error list as an error.
+func (r *runner) shutdownResult(err *Errors) error {
+	err.sep = r.errorSeparator
+	if r.dedupeShutdownErrors {
+		err = err.Deduplicate()
+	}
+	return err.result()
+}
+
+// Returns the indexes of the given tasks in the shutdown order.
+// By default, the tasks are shut down in reverse order of registration. If the
+// phases are set, the phases are shut down in the given order, and the tasks in
+// each phase are shut down in reverse order of registration. The tasks without
+// a known phase are shut down in the last default phase.
+func (r *runner) shutdownOrder(tasks []Task) []int {
+	order := make([]int, 0, len(tasks))
+	if len(r.phases) == 0 {
+		for i := len(tasks) - 1; i >= 0; i-- {
+			order = append(order, i)
+		}
+		return order
+	}
+
+	groups := make([][]int, len(r.phases)+1)
+	for i := len(tasks) - 1; i >= 0; i-- {
+		k := len(r.phases)
+		if p, ok := asPhasedTask(tasks[i]); ok {
+			for j := range r.phases {
+				if r.phases[j] == p.Phase() {
+					k = j
+					break
+				}
+			}
+		}
+		groups[k] = append(groups[k], i)
+	}
+	for _, group := range groups {
+		order = append(order, group...)
+	}
+	return order
+}
+
+// Shut down the given tasks in the given order in a separate coroutine.
+// If the shutdown exceeds the force exit time limit, we stop waiting for it and
+// report the tasks that have not been shut down to the force exit callback.
+// If the given context is done, we stop waiting for it and report the task that
+// did not finish in the returned error.
+func (r *runner) shutdownAllAsync(ctx context.Context, tasks []Task, order []int, shares []time.Duration, forceAt time.Time) error {
+	// The results channel is buffered, so the shutdown coroutine never blocks
+	// even if we stop waiting for it.
+	results := make(chan error, len(order))
+	stop := make(chan struct{})
+	// The events of the abandoned shutdown coroutine are dropped, so no event is
+	// emitted after the exit returns.
+	var mutex sync.Mutex
+	emit := func(kind EventKind, t Task, err error) {
+		mutex.Lock()
+		select {
+		case <-stop:
+		default:
+			r.emitLocked(kind, t, err)
+		}
+		mutex.Unlock()
+		r.flushEvents()
+	}
+	abandon := func() {
+		mutex.Lock()
+		close(stop)
+		mutex.Unlock()
+	}
+	go func() {
+		for k, i := range order {
+			select {
+			case <-stop:
+				return
+			default:
+				results <- r.shutdownStep(ctx, k, len(order), i, tasks[i], shares[i], emit)
+			}
+		}
+	}()
+
+	var timeout <-chan time.Time
+	if !forceAt.IsZero() {
+		timer := time.NewTimer(time.Until(forceAt))
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	err := new(Errors)
+	named := hasNamedTask(tasks)
+	for k := range order {
+		select {
+		case e := <-results:
+			addShutdownError(err, named, order[k], tasks[order[k]], e)
+		case <-ctx.Done():
+			abandon()
+			i := order[k]
+			err.Add(fmt.Errorf("runner: task #%d (%s) did not finish shutdown: %w", i, taskName(tasks[i]), ctx.Err()))
+			return r.shutdownResult(err)
+		case <-timeout:
+			abandon()
+			// The pending tasks are reported in the shutdown order, the first one
+			// is the task being shut down.
+			pending := make([]Task, 0, len(order)-k)
+			for _, i := range order[k:] {
+				pending = append(pending, exposedTask(tasks[i]))
+			}
+			if r.onForceExit != nil {
+				r.onForceExit(pending)
+			}
+			err.Add(ErrForcedExit)
+			return r.shutdownResult(err)
+		}
+	}
+	return r.shutdownResult(err)
+}
+
+// Shut down the given task with the index i and the given share of the shutdown budget,
+// and report the exit progress before and after it, the done is the number of the
+// tasks that have been shut down. The events are emitted by the given function.
+func (r *runner) shutdownStep(ctx context.Context, done, total, i int, t Task, share time.Duration,
+	emit func(EventKind, Task, error)) error {
+	r.reportExitProgress(done, total, t)
+	err := r.shutdown(ctx, i, t, share, emit)
+	r.reportExitProgress(done+1, total, t)
+	return err
+}
+
+// Call the exit progress callback with panic protection, the panic is only logged.
+func (r *runner) reportExitProgress(done, total int, t Task) {
+	if r.exitProgress == nil {
+		return
+	}
+	if err := SafeCall(func() error {
+		r.exitProgress(done, total, exposedTask(t))
+		return nil
+	}); err != nil {
+		r.logger.Printf("runner: exit progress callback panic: %s", err)
+	}
+}
+
+// Shut down the given task with the index i. If the task implements the ContextTask
+// interface, it receives a new context limited by the remaining budget of the given context.
+// If the given share of the shutdown budget is positive, the shutdown of the task is
+// also limited by it. The events are emitted by the given function.
+func (r *runner) shutdown(ctx context.Context, i int, t Task, share time.Duration,
+	emit func(EventKind, Task, error)) (err error) {
+	// The stack is only captured when the panic will be logged.
+	call := SafeCall
+	if r.logShutdownPanic {
+		call = SafeCallWithStack
+	}
+	timeout := share
+	if tt, ok := asTimeoutTask(t); ok {
+		if d := tt.ShutdownTimeout(); d > 0 && (timeout <= 0 || d < timeout) {
+			timeout = d
+		}
+	}
+	if d := r.hardShutdownDeadline; d > 0 && (timeout <= 0 || d < timeout) {
+		timeout = d
+	}
+	if timeout > 0 {
+		call = callWithTimeout(call, timeout)
+	}
+	emit(EventTaskShutdownStarted, t, nil)
+	if ct, ok := t.(ContextTask); ok {
+		c, cancel := r.withRemainingBudget(ctx)
+		defer cancel()
+		if share > 0 {
+			c, cancel = context.WithTimeout(c, share)
+			defer cancel()
+		}
+		err = call(func() error { return ct.ShutdownContext(c) })
+	} else {
+		err = call(t.Shutdown)
+	}
+	if err == nil {
+		emit(EventTaskShutdown, t, nil)
+		return
+	}
+	if e, ok := err.(*PanicError); ok && r.logShutdownPanic {
+		r.logger.Printf("runner: task #%d (%s) shutdown panic: %s\n%s", i, taskName(t), e, e.Stack())
+	}
+	emit(EventTaskShutdownError, t, err)
+	return
+}
+
+// Returns a function that calls the given function through the given call function
+// in a separate coroutine, and returns the ErrShutdownTimeout error if the call does
+// not return within the given duration, the call is abandoned.
+func callWithTimeout(call func(func() error) error, d time.Duration) func(func() error) error {
+	return func(f func() error) error {
+		// The channel is buffered, so the abandoned coroutine never blocks.
+		c := make(chan error, 1)
+		go func() { c <- call(f) }()
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		select {
+		case err := <-c:
+			return err
+		case <-timer.C:
+			return ErrShutdownTimeout
+		}
+	}
+}
+
+// Derive a context from the given context whose deadline is the remaining budget
+// measured by the runner clock. The elapsed time of the previous tasks is already
+// subtracted, so the budget shrinks as the shutdown progresses.
+func (r *runner) withRemainingBudget(ctx context.Context) (context.Context, context.CancelFunc) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return context.WithCancel(ctx)
 	}
-	return err.First()
+	return context.WithTimeout(ctx, deadline.Sub(r.now()))
+}
+
+// Returns the exit channel of the current run. The channel is replaced by the Reset
+// method under the mutex, and is loaded atomically, so the methods waiting for the
+// exit never take the lock.
+func (r *runner) exitChan() chan struct{} {
+	return r.chanExit.Load().(chan struct{})
 }
 
 // Close the current runner and exit channel.
 func (r *runner) closeExitChan() {
-	close(r.chanExit)
+	close(r.exitChan())
 }
 
 // Exited method determines whether the current runner has exited.
 func (r *runner) Exited() bool {
 	select {
-	case <-r.chanExit:
+	case <-r.exitChan():
 		return true
 	default:
 		return false
 	}
 }
+
+// Done method returns a channel that is closed when the current runner exits.
+func (r *runner) Done() <-chan struct{} {
+	return r.exitChan()
+}
+
+// Tasks method returns a snapshot of the tasks registered in the current runner in
+// the order of registration. After the runner exits, an empty slice is returned.
+func (r *runner) Tasks() []Task {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	tasks := make([]Task, len(r.tasks))
+	for i := range r.tasks {
+		tasks[i] = exposedTask(r.tasks[i])
+	}
+	return tasks
+}
+
+// Reset method resets the exited runner, so it can run tasks again.
+func (r *runner) Reset() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	if !r.Exited() {
+		return ErrNotExited
+	}
+	r.chanExit.Store(make(chan struct{}))
+	r.onceExit = sync.Once{}
+	r.deferredErrs = Errors{}
+	r.restarting = false
+	r.setState(func(s *RunnerState) { *s = RunnerState{} })
+	return nil
+}
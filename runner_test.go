@@ -15,10 +15,15 @@
 package runner
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"strings"
 	"sync"
 	"testing"
+	"time"
+
+	"github.com/edoger/zkits-runner/internal"
 )
 
 func TestNew(t *testing.T) {
@@ -187,3 +192,887 @@ func TestRunner_Wait(t *testing.T) {
 		}
 	})
 }
+
+type testContextTask struct {
+	Task
+	shutdown func(context.Context) error
+}
+
+func (t *testContextTask) ShutdownContext(ctx context.Context) error {
+	return t.shutdown(ctx)
+}
+
+func TestRunner_ExitContext(t *testing.T) {
+	r := New()
+	now := time.Now()
+	r.(*runner).now = func() time.Time { return now }
+
+	var budgets []time.Duration
+	for i := 0; i < 3; i++ {
+		r.MustRun(&testContextTask{Task: NewTaskFromFunc(nil), shutdown: func(ctx context.Context) error {
+			deadline, ok := ctx.Deadline()
+			if !ok {
+				return errors.New("no deadline")
+			}
+			budgets = append(budgets, time.Until(deadline))
+			now = now.Add(time.Second * 3)
+			return nil
+		}})
+	}
+
+	ctx, cancel := context.WithDeadline(context.Background(), now.Add(time.Second*10))
+	defer cancel()
+
+	if err := r.ExitContext(ctx); err != nil {
+		t.Fatalf("Runner.ExitContext(): %s", err)
+	}
+	if len(budgets) != 3 {
+		t.Fatalf("Runner.ExitContext(): %v", budgets)
+	}
+	for i, want := range []time.Duration{time.Second * 10, time.Second * 7, time.Second * 4} {
+		if got := budgets[i]; got > want || got < want-time.Second {
+			t.Fatalf("Runner.ExitContext(): %d %s", i, got)
+		}
+	}
+}
+
+func TestRunner_ExitContextWithoutDeadline(t *testing.T) {
+	r := New()
+	r.MustRun(&testContextTask{Task: NewTaskFromFunc(nil), shutdown: func(ctx context.Context) error {
+		if _, ok := ctx.Deadline(); ok {
+			return errors.New("unexpected deadline")
+		}
+		return nil
+	}})
+
+	if err := r.ExitContext(context.Background()); err != nil {
+		t.Fatalf("Runner.ExitContext(): %s", err)
+	}
+}
+
+func TestRunner_RunDeferred(t *testing.T) {
+	r := New()
+
+	var ss []string
+	mutex := new(sync.Mutex)
+	record := func(s string) func() error {
+		return func() error {
+			mutex.Lock()
+			ss = append(ss, s)
+			mutex.Unlock()
+			return nil
+		}
+	}
+
+	release := make(chan struct{})
+	r.RunDeferred(NewTaskFromFunc(func() error {
+		<-release
+		return record("A1")()
+	}, record("B1")))
+	r.RunDeferred(NewTaskFromFunc(func() error { return errors.New("test1") }, record("B2")))
+	r.RunDeferred(NewTaskFromFunc(func() error { panic("test2") }, record("B3")))
+
+	ch := make(chan struct{})
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		close(release)
+		close(ch)
+	}()
+
+	err := r.WaitBy(ch)
+	if err == nil {
+		t.Fatal("Runner.WaitBy(): nil")
+	}
+	if errs, ok := err.(*Errors); !ok || errs.Len() != 2 {
+		t.Fatalf("Runner.WaitBy(): %s", err)
+	}
+	if got := strings.Join(ss, "-"); got != "A1-B1" {
+		t.Fatalf("Runner.WaitBy(): %s", got)
+	}
+
+	if err := r.RunDeferred(NewTaskFromFunc(nil)); err != ErrExited {
+		t.Fatalf("Runner.RunDeferred(): %v", err)
+	}
+	// The errors of the previous executions are still reported, but not ErrExited.
+	if err := r.Wait(); err == nil {
+		t.Fatal("Runner.Wait(): nil")
+	} else {
+		if errs, ok := err.(*Errors); !ok || errs.Len() != 2 || errs.Contains(ErrExited) {
+			t.Fatalf("Runner.Wait(): %s", err)
+		}
+	}
+}
+
+func TestRunner_RunDeferred_ExitAborted(t *testing.T) {
+	for _, exit := range []func(Runner) error{
+		func(r Runner) error { return r.ExitWithTimeout(time.Millisecond * 10) },
+		func(r Runner) error { return r.Exit() },
+	} {
+		shutdown := make(chan struct{})
+		release := make(chan struct{})
+		r := New(WithForceExitAfter(time.Millisecond*20, nil))
+		if err := r.RunDeferred(NewTaskFromFunc(func() error {
+			<-release
+			return nil
+		}, func() error {
+			close(shutdown)
+			return nil
+		})); err != nil {
+			t.Fatalf("Runner.RunDeferred(): %s", err)
+		}
+
+		err := exit(r)
+		if err == nil || !strings.Contains(err.Error(), "did not finish execution") {
+			t.Fatalf("Runner.Exit(): %v", err)
+		}
+		if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrForcedExit) {
+			t.Fatalf("Runner.Exit(): %v", err)
+		}
+		// The abandoned task is shut down as soon as its execution completes.
+		close(release)
+		<-shutdown
+		if tasks := r.Tasks(); len(tasks) != 0 {
+			t.Fatalf("Runner.Tasks(): %v", tasks)
+		}
+	}
+}
+
+type testOptionalTask struct {
+	Task
+	optional bool
+}
+
+func (t *testOptionalTask) Optional() bool { return t.optional }
+
+func TestRunner_RunOptionalTask(t *testing.T) {
+	logger := new(testLogger)
+	r := New(WithLogger(logger))
+
+	var n int
+	shutdown := func() error {
+		n++
+		return nil
+	}
+
+	if err := r.Run(&testOptionalTask{
+		Task:     NewTaskFromFunc(func() error { return errors.New("test error") }, shutdown),
+		optional: true,
+	}); err != nil {
+		t.Fatalf("Runner.Run(): %s", err)
+	}
+	if err := r.Run(&testOptionalTask{
+		Task:     NewTaskFromFunc(func() error { panic("test panic") }, shutdown),
+		optional: true,
+	}); err != nil {
+		t.Fatalf("Runner.Run(): %s", err)
+	}
+	if err := r.Run(&testOptionalTask{
+		Task: NewTaskFromFunc(func() error { return errors.New("test error") }, shutdown),
+	}); err == nil {
+		t.Fatal("Runner.Run(): nil")
+	}
+	r.RunDeferred(&testOptionalTask{
+		Task:     NewTaskFromFunc(func() error { panic("test panic") }, shutdown),
+		optional: true,
+	})
+
+	if err := r.WaitBy(internal.ClosedChan()); err != nil {
+		t.Fatalf("Runner.WaitBy(): %s", err)
+	}
+	if n != 0 {
+		t.Fatalf("Runner.Exit(): %d", n)
+	}
+	if messages := logger.Messages(); len(messages) != 3 {
+		t.Fatalf("Runner.Run(): %v", messages)
+	}
+}
+
+func TestRunner_WaitByReason(t *testing.T) {
+	r := New()
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test") }))
+
+	ch := make(chan struct{})
+	close(ch)
+	if reason, err := r.WaitByReason(ch); reason != ReasonChannel || err == nil {
+		t.Fatalf("Runner.WaitByReason(): %s %v", reason, err)
+	}
+	if reason, err := r.WaitByReason(ch); reason != ReasonExplicit || err != nil {
+		t.Fatalf("Runner.WaitByReason(): %s %v", reason, err)
+	}
+
+	r = New()
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		_ = r.Exit()
+	}()
+	if reason, err := r.WaitByReason(make(chan struct{})); reason != ReasonExplicit || err != nil {
+		t.Fatalf("Runner.WaitByReason(): %s %v", reason, err)
+	}
+}
+
+func TestRunner_ExitSeparated(t *testing.T) {
+	r := New()
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test1") }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { panic("test2") }))
+	r.MustRun(NewNamedTaskFromFunc("cache", nil, func() error { panic("test3") }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("test4") }))
+
+	errs, panics := r.ExitSeparated()
+	if errs == nil || errs.Error() != "#3: test4; #0: test1" {
+		t.Fatalf("Runner.ExitSeparated(): errs %v", errs)
+	}
+	if panics == nil || panics.Error() != "cache: test3; #1: test2" {
+		t.Fatalf("Runner.ExitSeparated(): panics %v", panics)
+	}
+
+	// There is no error of either kind.
+	errs, panics = r.ExitSeparated()
+	if errs != nil || panics != nil {
+		t.Fatalf("Runner.ExitSeparated(): %v %v", errs, panics)
+	}
+
+	r = New()
+	r.MustRun(NewTaskFromFunc(nil, func() error { panic("test") }))
+	if errs, panics := r.ExitSeparated(); errs != nil || panics == nil || panics.Len() != 1 {
+		t.Fatalf("Runner.ExitSeparated(): %v %v", errs, panics)
+	}
+}
+
+func TestRunner_GracefulRestart(t *testing.T) {
+	r := New()
+	var ss []string
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		ss = append(ss, "shutdown")
+		return nil
+	}))
+	r.OnGracefulRestart(func() error {
+		ss = append(ss, "restart")
+		return nil
+	})
+
+	done := make(chan ExitReason, 1)
+	go func() {
+		reason, _ := r.WaitByReason(make(chan struct{}))
+		done <- reason
+	}()
+	if err := r.GracefulRestart(); err != nil {
+		t.Fatalf("Runner.GracefulRestart(): %s", err)
+	}
+	if reason := <-done; reason != ReasonRestart {
+		t.Fatalf("Runner.WaitByReason(): %s", reason)
+	}
+	if reason, _ := r.WaitByReason(make(chan struct{})); reason != ReasonRestart {
+		t.Fatalf("Runner.WaitByReason(): %s", reason)
+	}
+	if got := strings.Join(ss, ","); got != "restart,shutdown" {
+		t.Fatalf("Runner.GracefulRestart(): %s", got)
+	}
+	if err := r.GracefulRestart(); err != ErrExited {
+		t.Fatalf("Runner.GracefulRestart(): %v", err)
+	}
+
+	// The failed hook aborts the restart.
+	r = New()
+	r.OnGracefulRestart(func() error { return errors.New("test") })
+	r.OnGracefulRestart(func() error {
+		t.Fatal("Runner.OnGracefulRestart(): hook called")
+		return nil
+	})
+	if err := r.GracefulRestart(); err == nil || err.Error() != "test" {
+		t.Fatalf("Runner.GracefulRestart(): %v", err)
+	}
+	if r.Exited() {
+		t.Fatal("Runner.GracefulRestart(): exited")
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if reason, _ := r.WaitByReason(make(chan struct{})); reason != ReasonExplicit {
+		t.Fatalf("Runner.WaitByReason(): %s", reason)
+	}
+}
+
+func TestRunner_WaitReadyTimeout(t *testing.T) {
+	r := New()
+	if err := r.WaitReadyTimeout(time.Millisecond); err != nil {
+		t.Fatalf("Runner.WaitReadyTimeout(): %s", err)
+	}
+
+	release := make(chan struct{})
+	r.RunDeferred(NewTaskFromFunc(func() error {
+		<-release
+		return nil
+	}))
+	r.RunDeferred(NewTaskFromFunc(nil))
+
+	err := r.WaitReadyTimeout(time.Millisecond * 10)
+	if !errors.Is(err, ErrReadyTimeout) {
+		t.Fatalf("Runner.WaitReadyTimeout(): %v", err)
+	}
+	if !strings.Contains(err.Error(), "*runner.funcTask") {
+		t.Fatalf("Runner.WaitReadyTimeout(): %s", err)
+	}
+
+	go func() {
+		time.Sleep(time.Millisecond * 10)
+		close(release)
+	}()
+	if err := r.WaitReadyTimeout(time.Second); err != nil {
+		t.Fatalf("Runner.WaitReadyTimeout(): %s", err)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+}
+
+func TestRunner_RunUntil(t *testing.T) {
+	var deadlines []bool
+	newTask := func() Task {
+		return &testContextTask{Task: NewTaskFromFunc(nil), shutdown: func(ctx context.Context) error {
+			_, ok := ctx.Deadline()
+			deadlines = append(deadlines, ok)
+			return ctx.Err()
+		}}
+	}
+
+	// The context is canceled, and there is no grace period.
+	r := New()
+	r.MustRun(newTask())
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := r.RunUntil(ctx); err != nil {
+		t.Fatalf("Runner.RunUntil(): %s", err)
+	}
+	if !r.Exited() || len(deadlines) != 1 || deadlines[0] {
+		t.Fatalf("Runner.RunUntil(): %v", deadlines)
+	}
+
+	// The context is canceled, and the grace period is set.
+	r = New(WithGracePeriod(time.Second))
+	r.MustRun(newTask())
+	if err := r.RunUntil(ctx); err != nil {
+		t.Fatalf("Runner.RunUntil(): %s", err)
+	}
+	if len(deadlines) != 2 || !deadlines[1] {
+		t.Fatalf("Runner.RunUntil(): %v", deadlines)
+	}
+
+	// The exit signal is captured before the deadline of the context.
+	r = New()
+	r.MustRun(newTask())
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	c := make(chan struct{})
+	close(c)
+	if err := r.(*runner).runUntil(ctx, c); err != nil {
+		t.Fatalf("Runner.RunUntil(): %s", err)
+	}
+	if len(deadlines) != 3 || !deadlines[2] {
+		t.Fatalf("Runner.RunUntil(): %v", deadlines)
+	}
+
+	// The Exit method is called.
+	r = New()
+	r.MustRun(newTask())
+	go func() {
+		if err := r.Exit(); err != nil {
+			t.Errorf("Runner.Exit(): %s", err)
+		}
+	}()
+	if err := r.RunUntil(context.Background()); err != nil {
+		t.Fatalf("Runner.RunUntil(): %s", err)
+	}
+	if err := r.RunUntil(context.Background()); err != nil {
+		t.Fatalf("Runner.RunUntil(): %s", err)
+	}
+}
+
+func TestRunner_Done(t *testing.T) {
+	r := New()
+	select {
+	case <-r.Done():
+		t.Fatal("Runner.Done(): closed")
+	default:
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	<-r.Done()
+}
+
+func TestRunner_WaitFor(t *testing.T) {
+	r := New()
+	var shutdown bool
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		shutdown = true
+		return nil
+	}))
+
+	w := NewCloseableWaiter()
+	w.Close()
+	if err := r.WaitFor(WaitAny(w.Waiter(), NewCloseableWaiter().Waiter())); err != nil {
+		t.Fatalf("Runner.WaitFor(): %s", err)
+	}
+	if !r.Exited() || !shutdown {
+		t.Fatal("Runner.WaitFor(): not exited")
+	}
+
+	// The runner has exited.
+	if err := r.WaitFor(NewCloseableWaiter()); err != nil {
+		t.Fatalf("Runner.WaitFor(): %s", err)
+	}
+}
+
+type testExecuteContextTask struct {
+	Task
+	execute func(context.Context) error
+}
+
+func (t *testExecuteContextTask) ExecuteContext(ctx context.Context) error {
+	return t.execute(ctx)
+}
+
+type testRunnerAwareTask struct {
+	Task
+	execute func(TaskRunner) error
+}
+
+func (t *testRunnerAwareTask) ExecuteWithRunner(r TaskRunner) error {
+	return t.execute(r)
+}
+
+func TestRunner_RunnerAwareTask(t *testing.T) {
+	var ss []string
+	record := func(s string) func() error {
+		return func() error {
+			ss = append(ss, s)
+			return nil
+		}
+	}
+
+	r := New()
+	parent := &testRunnerAwareTask{Task: NewTaskFromFunc(nil, record("parent")), execute: func(r TaskRunner) error {
+		if err := r.Run(NewTaskFromFunc(nil, record("child1"))); err != nil {
+			return err
+		}
+		if err := r.RunContext(context.Background(), NewTaskFromFunc(nil, record("child2"))); err != nil {
+			return err
+		}
+		return r.Run(NewBackgroundTask(func(c chan<- struct{}) error {
+			close(c)
+			return nil
+		}, record("child3")))
+	}}
+	if err := r.Run(parent); err != nil {
+		t.Fatalf("Runner.Run(): %s", err)
+	}
+	if tasks := r.Tasks(); len(tasks) != 4 || tasks[3] != Task(parent) {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+
+	// The deferred runner-aware task registers its sub-tasks with the runner itself.
+	r.RunDeferred(&testRunnerAwareTask{Task: NewTaskFromFunc(nil, record("deferred")), execute: func(r TaskRunner) error {
+		return r.Run(NewTaskFromFunc(nil, record("child4")))
+	}})
+	if err := r.WaitReadyTimeout(time.Second); err != nil {
+		t.Fatalf("Runner.WaitReadyTimeout(): %s", err)
+	}
+
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := strings.Join(ss, ","); got != "deferred,child4,parent,child3,child2,child1" {
+		t.Fatalf("Runner.Exit(): %s", got)
+	}
+
+	// The failed runner-aware task is not registered, but its sub-tasks are.
+	r = New()
+	err := r.Run(&testRunnerAwareTask{Task: NewTaskFromFunc(nil, nil), execute: func(r TaskRunner) error {
+		if err := r.Run(NewTaskFromFunc(nil, nil)); err != nil {
+			return err
+		}
+		panic("test")
+	}})
+	if !IsPanicError(err) || len(r.Tasks()) != 1 {
+		t.Fatalf("Runner.Run(): %v %d", err, len(r.Tasks()))
+	}
+}
+
+func TestRunner_RunnerAwareTask_Nested(t *testing.T) {
+	r := New()
+	child := &testRunnerAwareTask{Task: NewTaskFromFunc(nil, nil), execute: func(tr TaskRunner) error {
+		return tr.Run(NewTaskFromFunc(nil, nil))
+	}}
+	parent := &testRunnerAwareTask{Task: NewTaskFromFunc(nil, nil), execute: func(tr TaskRunner) error {
+		// The runner holds its lock, so the methods that take the lock are not exposed.
+		if _, ok := tr.(interface{ Tasks() []Task }); ok {
+			return errors.New("the locking methods are exposed")
+		}
+		return tr.Run(child)
+	}}
+
+	done := make(chan error, 1)
+	go func() { done <- r.Run(parent) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("Runner.Run(): %s", err)
+		}
+	case <-time.After(time.Second * 5):
+		t.Fatal("Runner.Run(): deadlock")
+	}
+	if n := len(r.Tasks()); n != 3 {
+		t.Fatalf("Runner.Tasks(): %d", n)
+	}
+}
+
+func TestRunner_RunContext(t *testing.T) {
+	r := New()
+	type key struct{}
+	ctx := context.WithValue(context.Background(), key{}, "test")
+
+	var got interface{}
+	err := r.RunContext(ctx, &testExecuteContextTask{Task: NewTaskFromFunc(nil), execute: func(ctx context.Context) error {
+		got = ctx.Value(key{})
+		return nil
+	}})
+	if err != nil || got != "test" {
+		t.Fatalf("Runner.RunContext(): %v %v", err, got)
+	}
+
+	canceled, cancel := context.WithCancel(ctx)
+	cancel()
+	if err := r.RunContext(canceled, NewTaskFromFunc(func() error {
+		t.Error("Runner.RunContext(): executed")
+		return nil
+	})); err != context.Canceled {
+		t.Fatalf("Runner.RunContext(): %v", err)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+}
+
+func TestRunner_ExitContext_Abort(t *testing.T) {
+	r := New()
+	release := make(chan struct{})
+	defer close(release)
+
+	var shutdown bool
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		shutdown = true
+		return nil
+	}))
+	r.MustRun(NewObservedTask(NewTaskFromFunc(nil, func() error {
+		<-release
+		return nil
+	}), "hung", new(testLogger)))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err := r.ExitContext(ctx)
+	if err == nil || !errors.Is(err, context.DeadlineExceeded) ||
+		err.Error() != "runner: task #1 (hung) did not finish shutdown: context deadline exceeded" {
+		t.Fatalf("Runner.ExitContext(): %v", err)
+	}
+	if !r.Exited() || shutdown {
+		t.Fatal("Runner.ExitContext(): not aborted")
+	}
+}
+
+func TestRunner_Exit_ShutdownTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var ss []string
+	r := New()
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		ss = append(ss, "db")
+		return errors.New("db")
+	}))
+	r.MustRun(NewTaskFromFuncWithTimeout(nil, func() error {
+		<-release
+		return nil
+	}, time.Millisecond*20))
+	r.MustRun(NewTaskFromFuncWithTimeout(nil, func() error {
+		ss = append(ss, "cache")
+		return nil
+	}, time.Second))
+
+	err := r.Exit()
+	if err == nil || !errors.Is(err.(*Errors).First(), ErrShutdownTimeout) ||
+		err.Error() != "runner: shutdown timeout; db" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+	if got := strings.Join(ss, "-"); got != "cache-db" {
+		t.Fatalf("Runner.Exit(): %s", got)
+	}
+}
+
+func TestRunner_Exit_NamedTask(t *testing.T) {
+	err1 := errors.New("err1")
+	r := New()
+	r.MustRun(NewNamedTaskFromFunc("db", nil, func() error { return err1 }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("err2") }))
+	r.MustRun(NewNamedTaskFromFunc("cache", nil, func() error { return errors.New("err3") }))
+
+	err := r.Exit()
+	if err == nil || err.Error() != "cache: err3; #1: err2; db: err1" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+	if !errors.Is(err.(*Errors).Last(), err1) {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+
+	// The errors are not annotated if no task is named.
+	r = New()
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("err1") }))
+	r.MustRun(NewTaskFromFunc(nil, func() error { return errors.New("err2") }))
+	if err := r.Exit(); err == nil || err.Error() != "err2; err1" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+}
+
+type testWeightedTask struct {
+	Task
+	weight int
+}
+
+func (t *testWeightedTask) ShutdownWeight() int {
+	return t.weight
+}
+
+type testWeightedContextTask struct {
+	*testContextTask
+	weight int
+}
+
+func (t *testWeightedContextTask) ShutdownWeight() int {
+	return t.weight
+}
+
+func TestShutdownShares(t *testing.T) {
+	tasks := []Task{
+		&testWeightedTask{Task: NewTaskFromFunc(nil), weight: 5},
+		NewTaskFromFunc(nil),
+	}
+	if got := fmt.Sprint(shutdownShares(tasks, time.Second*30)); got != "[25s 5s]" {
+		t.Fatalf("shutdownShares(): %s", got)
+	}
+	tasks = []Task{NewTaskFromFunc(nil), &testWeightedTask{Task: NewTaskFromFunc(nil), weight: -1}}
+	if got := fmt.Sprint(shutdownShares(tasks, time.Second*30)); got != "[15s 15s]" {
+		t.Fatalf("shutdownShares(): %s", got)
+	}
+	if got := fmt.Sprint(shutdownShares(tasks, 0)); got != "[0s 0s]" {
+		t.Fatalf("shutdownShares(): %s", got)
+	}
+}
+
+func TestRunner_ExitWithTimeout(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+
+	var budget time.Duration
+	r := New()
+	r.MustRun(&testWeightedContextTask{testContextTask: &testContextTask{
+		Task: NewTaskFromFunc(nil),
+		shutdown: func(ctx context.Context) error {
+			deadline, _ := ctx.Deadline()
+			budget = time.Until(deadline)
+			return nil
+		},
+	}, weight: 3})
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		<-release
+		return nil
+	}))
+
+	err := r.ExitWithTimeout(time.Second * 2)
+	if err != ErrShutdownTimeout {
+		t.Fatalf("Runner.ExitWithTimeout(): %v", err)
+	}
+	// The weighted task receives 3/4 of the budget, minus the 500ms of the hung task.
+	if budget <= 0 || budget > time.Millisecond*1500 {
+		t.Fatalf("Runner.ExitWithTimeout(): %s", budget)
+	}
+}
+
+func TestRunner_Tasks(t *testing.T) {
+	r := New()
+	if tasks := r.Tasks(); tasks == nil || len(tasks) != 0 {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+
+	r.MustRun(NewNamedTaskFromFunc("db", nil, nil))
+	r.MustRun(NewNamedTaskFromFunc("cache", nil, nil))
+	tasks := r.Tasks()
+	if len(tasks) != 2 || taskName(tasks[0]) != "db" || taskName(tasks[1]) != "cache" {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+
+	// The snapshot can not corrupt the runner.
+	tasks[0] = nil
+	if taskName(r.Tasks()[0]) != "db" {
+		t.Fatal("Runner.Tasks(): not a snapshot")
+	}
+
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if tasks := r.Tasks(); tasks == nil || len(tasks) != 0 {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+}
+
+func TestRunner_ExitHooks(t *testing.T) {
+	logger := new(testLogger)
+	r := New(WithLogger(logger))
+
+	var ss []string
+	r.OnBeforeExit(func() { ss = append(ss, "before1") })
+	r.OnBeforeExit(func() { panic("test") })
+	r.OnBeforeExit(func() { ss = append(ss, "before2") })
+	r.OnAfterExit(func(err error) { ss = append(ss, "after1: "+err.Error()) })
+	r.OnAfterExit(func(error) { panic("test") })
+	r.OnAfterExit(func(err error) { ss = append(ss, "after2: "+err.Error()) })
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		ss = append(ss, "shutdown")
+		return errors.New("err")
+	}))
+
+	if err := r.Exit(); err == nil {
+		t.Fatal("Runner.Exit(): nil")
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := strings.Join(ss, ", "); got != "before1, before2, shutdown, after1: err, after2: err" {
+		t.Fatalf("Runner.Exit(): %s", got)
+	}
+	if got := strings.Join(logger.Messages(), ", "); got != "runner: before exit hook panic: test, runner: after exit hook panic: test" {
+		t.Fatalf("Runner.Exit(): %s", got)
+	}
+}
+
+func TestRunner_RunIf(t *testing.T) {
+	var ss []string
+	newTask := func(name string) Task {
+		return NewNamedTaskFromFunc(name, func() error {
+			ss = append(ss, "execute "+name)
+			return nil
+		}, func() error {
+			ss = append(ss, "shutdown "+name)
+			return nil
+		})
+	}
+
+	r := New()
+	if err := r.RunIf(false, newTask("a")); err != nil {
+		t.Fatalf("Runner.RunIf(): %s", err)
+	}
+	if err := r.RunIf(true, newTask("b")); err != nil {
+		t.Fatalf("Runner.RunIf(): %s", err)
+	}
+	r.MustRunIf(false, newTask("c")).MustRunIf(true, newTask("d"))
+	if err := r.RunUnless(true, newTask("e")); err != nil {
+		t.Fatalf("Runner.RunUnless(): %s", err)
+	}
+	if err := r.RunUnless(false, newTask("f")); err != nil {
+		t.Fatalf("Runner.RunUnless(): %s", err)
+	}
+	if n := len(r.Tasks()); n != 3 {
+		t.Fatalf("Runner.Tasks(): %d", n)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := strings.Join(ss, ", "); got != "execute b, execute d, execute f, shutdown f, shutdown d, shutdown b" {
+		t.Fatalf("Runner.RunIf(): %s", got)
+	}
+}
+
+func TestRunner_WaitEx(t *testing.T) {
+	r := New()
+	c := make(chan struct{})
+	close(c)
+	if reason, err := r.(*runner).waitEx(c); reason != ReasonSignal || err != nil {
+		t.Fatalf("Runner.WaitEx(): %s %v", reason, err)
+	}
+	if !r.Exited() {
+		t.Fatal("Runner.WaitEx(): not exited")
+	}
+
+	r = New()
+	go func() {
+		if err := r.Exit(); err != nil {
+			t.Errorf("Runner.Exit(): %s", err)
+		}
+	}()
+	if reason, err := r.WaitEx(); reason != ReasonExitCall || err != nil {
+		t.Fatalf("Runner.WaitEx(): %s %v", reason, err)
+	}
+}
+
+func TestRunner_Reset(t *testing.T) {
+	r := New()
+	if err := r.Reset(); err != ErrNotExited {
+		t.Fatalf("Runner.Reset(): %v", err)
+	}
+
+	var n int
+	task := NewTaskFromFunc(nil, func() error {
+		n++
+		return nil
+	})
+	for i := 0; i < 3; i++ {
+		r.MustRun(task)
+		done := r.Done()
+		if err := r.Exit(); err != nil {
+			t.Fatalf("Runner.Exit(): %s", err)
+		}
+		<-done
+		if err := r.Reset(); err != nil {
+			t.Fatalf("Runner.Reset(): %s", err)
+		}
+		if r.Exited() {
+			t.Fatal("Runner.Reset(): exited")
+		}
+		// The channel of the previous run remains closed.
+		<-done
+	}
+	if n != 3 {
+		t.Fatalf("Runner.Reset(): %d", n)
+	}
+	if err := r.Reset(); err != ErrNotExited {
+		t.Fatalf("Runner.Reset(): %v", err)
+	}
+}
+
+func TestRunner_Reset_Concurrent(t *testing.T) {
+	r := New()
+	stop := make(chan struct{})
+	wg := new(sync.WaitGroup)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				r.Exited()
+				r.Done()
+			}
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := r.Exit(); err != nil {
+			t.Fatalf("Runner.Exit(): %s", err)
+		}
+		if err := r.Reset(); err != nil {
+			t.Fatalf("Runner.Reset(): %s", err)
+		}
+	}
+	close(stop)
+	wg.Wait()
+}
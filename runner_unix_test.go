@@ -0,0 +1,65 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package runner
+
+import (
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestRunner_WaitSignals(t *testing.T) {
+	r := New()
+	var shutdown bool
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		shutdown = true
+		return nil
+	}))
+
+	go func() {
+		// Give the runner some time to listen to the signal.
+		time.Sleep(time.Millisecond * 50)
+		if err := syscall.Kill(os.Getpid(), syscall.SIGUSR2); err != nil {
+			t.Errorf("syscall.Kill(): %s", err)
+		}
+	}()
+	sig, err := r.WaitSignals(syscall.SIGUSR2)
+	if sig != syscall.SIGUSR2 || err != nil {
+		t.Fatalf("Runner.WaitSignals(): %v %v", sig, err)
+	}
+	if !r.Exited() || !shutdown {
+		t.Fatal("Runner.WaitSignals(): not exited")
+	}
+
+	// The runner has exited.
+	if sig, err := r.WaitSignals(syscall.SIGUSR2); sig != nil || err != nil {
+		t.Fatalf("Runner.WaitSignals(): %v %v", sig, err)
+	}
+
+	// The exit method is called.
+	r = New()
+	go func() {
+		if err := r.Exit(); err != nil {
+			t.Errorf("Runner.Exit(): %s", err)
+		}
+	}()
+	if sig, err := r.WaitSignals(syscall.SIGUSR2); sig != nil || err != nil {
+		t.Fatalf("Runner.WaitSignals(): %v %v", sig, err)
+	}
+}
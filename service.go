@@ -0,0 +1,110 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+)
+
+// Service interface defines the long-lived service.
+type Service interface {
+	// Start method runs the service and blocks until the service stops.
+	// The given ready function must be called when the service is ready to serve,
+	// it can be called multiple times. The given context is canceled when the
+	// service is shut down, returning the context.Canceled error is a normal exit.
+	Start(ctx context.Context, ready func()) error
+
+	// Stop method stops the service, it is called after the context of the
+	// Start method is canceled.
+	Stop(ctx context.Context) error
+}
+
+// NewService creates a task from the given service.
+// The Execute method runs the Start method of the service in a new coroutine, and
+// returns after the service is ready, or returns the error of the Start method if it
+// returns before the service is ready. The Shutdown method cancels the context of the
+// Start method, calls the Stop method of the service, then waits for the Start method
+// to return. The task implements the ContextTask interface, so the context given
+// to the Stop method is bounded by the shutdown budget of the runner.
+func NewService(s Service) Task {
+	return &serviceTask{service: s}
+}
+
+// The serviceTask type is used to wrap a service into a runnable task.
+type serviceTask struct {
+	service Service
+	cancel  context.CancelFunc
+	// The waiter is closed when the service is ready, and it is done when
+	// the Start method of the service returns.
+	waiter DuplexWaiter
+	err    error
+}
+
+// Execute method starts the service and waits for it to be ready.
+func (t *serviceTask) Execute() error {
+	ctx, cancel := context.WithCancel(context.Background())
+	t.cancel = cancel
+	t.waiter = NewDuplexWaiter()
+	goManaged(func() { t.start(ctx) }, t.waiter.Done)
+
+	select {
+	case <-t.waiter.Channel():
+		return nil
+	case <-t.waiter.DoneChannel():
+		cancel()
+		select {
+		case <-t.waiter.Channel():
+			// The service is ready before the Start method returns.
+			return nil
+		default:
+			return t.err
+		}
+	}
+}
+
+// Run the Start method of the service.
+func (t *serviceTask) start(ctx context.Context) {
+	t.err = SafeCall(func() error { return t.service.Start(ctx, t.waiter.Close) })
+}
+
+// Shutdown method stops the service and waits for the Start method to return.
+func (t *serviceTask) Shutdown() error {
+	return t.ShutdownContext(context.Background())
+}
+
+// ShutdownContext method stops the service with the given context and waits for
+// the Start method to return. If the given context is done before the Start method
+// returns, the error of the context is returned.
+func (t *serviceTask) ShutdownContext(ctx context.Context) error {
+	if t.waiter == nil {
+		// The service has not been started.
+		return nil
+	}
+
+	t.cancel()
+	errs := new(Errors)
+	errs.Add(SafeCall(func() error { return t.service.Stop(ctx) }))
+
+	select {
+	case <-t.waiter.DoneChannel():
+		if !errors.Is(t.err, context.Canceled) {
+			errs.Add(t.err)
+		}
+	case <-ctx.Done():
+		errs.Add(ctx.Err())
+	}
+	return errs.result()
+}
@@ -0,0 +1,139 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type testService struct {
+	mutex sync.Mutex
+	steps []string
+	start func(context.Context, func()) error
+	stop  func(context.Context) error
+}
+
+func (s *testService) record(step string) {
+	s.mutex.Lock()
+	s.steps = append(s.steps, step)
+	s.mutex.Unlock()
+}
+
+func (s *testService) Steps() string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return strings.Join(s.steps, "-")
+}
+
+func (s *testService) Start(ctx context.Context, ready func()) error {
+	return s.start(ctx, ready)
+}
+
+func (s *testService) Stop(ctx context.Context) error {
+	s.record("stop")
+	if s.stop != nil {
+		return s.stop(ctx)
+	}
+	return nil
+}
+
+func TestNewService(t *testing.T) {
+	s := new(testService)
+	s.start = func(ctx context.Context, ready func()) error {
+		s.record("start")
+		ready()
+		ready()
+		<-ctx.Done()
+		s.record("canceled")
+		return ctx.Err()
+	}
+
+	r := New()
+	if err := r.Run(NewService(s)); err != nil {
+		t.Fatalf("Runner.Run(): %s", err)
+	}
+	if got := s.Steps(); got != "start" {
+		t.Fatalf("NewService(): %s", got)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if got := s.Steps(); got != "start-canceled-stop" && got != "start-stop-canceled" {
+		t.Fatalf("NewService(): %s", got)
+	}
+}
+
+func TestNewService_StartError(t *testing.T) {
+	s := new(testService)
+	s.start = func(ctx context.Context, ready func()) error {
+		return errors.New("test")
+	}
+
+	if err := New().Run(NewService(s)); err == nil || err.Error() != "test" {
+		t.Fatalf("Runner.Run(): %v", err)
+	}
+
+	s.start = func(ctx context.Context, ready func()) error { panic("test") }
+	if err := New().Run(NewService(s)); !IsPanicError(err) {
+		t.Fatalf("Runner.Run(): %v", err)
+	}
+
+	// The error after the service is ready is reported by the shutdown.
+	s.start = func(ctx context.Context, ready func()) error {
+		ready()
+		return errors.New("test")
+	}
+	task := NewService(s)
+	if err := task.Execute(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+	if err := task.Shutdown(); err == nil || err.Error() != "test" {
+		t.Fatalf("Task.Shutdown(): %v", err)
+	}
+}
+
+func TestNewService_ShutdownContext(t *testing.T) {
+	s := new(testService)
+	release := make(chan struct{})
+	defer close(release)
+
+	s.start = func(ctx context.Context, ready func()) error {
+		ready()
+		<-release
+		return nil
+	}
+	s.stop = func(ctx context.Context) error { return errors.New("stop") }
+
+	task := NewService(s).(ContextTask)
+	if err := task.Execute(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	err := task.ShutdownContext(ctx)
+	if errs, ok := err.(*Errors); !ok || errs.Len() != 2 || !errs.Contains(context.DeadlineExceeded) {
+		t.Fatalf("Task.ShutdownContext(): %v", err)
+	}
+
+	if err := NewService(s).Shutdown(); err != nil {
+		t.Fatalf("Task.Shutdown(): %s", err)
+	}
+}
@@ -0,0 +1,109 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"sync"
+)
+
+// Severity defines the severity of the error.
+type Severity int
+
+// These are the severities of the error, from low to high.
+const (
+	// SeverityNone means that there is no error.
+	SeverityNone Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+	SeverityCritical
+)
+
+// String returns the name of the current severity.
+func (s Severity) String() string {
+	switch s {
+	case SeverityNone:
+		return "none"
+	case SeverityInfo:
+		return "info"
+	case SeverityWarning:
+		return "warning"
+	case SeverityError:
+		return "error"
+	case SeverityCritical:
+		return "critical"
+	}
+	return "unknown"
+}
+
+var (
+	severityMutex = new(sync.RWMutex)
+	severityFunc  = DefaultSeverityFunc
+)
+
+// DefaultSeverityFunc is the default error classifier.
+// It treats the PanicError, or the error that wraps a PanicError, such as the shutdown
+// error annotated with the task name, as critical and everything else as error.
+func DefaultSeverityFunc(err error) Severity {
+	if errors.As(err, new(*PanicError)) {
+		return SeverityCritical
+	}
+	return SeverityError
+}
+
+// SetSeverityFunc sets the error classifier used by the Errors.BySeverity and
+// Errors.HighestSeverity methods. If the given function is nil, the default
+// classifier DefaultSeverityFunc is restored.
+func SetSeverityFunc(f func(error) Severity) {
+	if f == nil {
+		f = DefaultSeverityFunc
+	}
+	severityMutex.Lock()
+	severityFunc = f
+	severityMutex.Unlock()
+}
+
+// Returns the current error classifier.
+func getSeverityFunc() func(error) Severity {
+	severityMutex.RLock()
+	defer severityMutex.RUnlock()
+	return severityFunc
+}
+
+// BySeverity method partitions the errors in the current error list by severity.
+// The order of the errors in each partition is the same as the current error list.
+func (e *Errors) BySeverity() map[Severity][]error {
+	f := getSeverityFunc()
+	m := make(map[Severity][]error)
+	for i, j := 0, len(e.errs); i < j; i++ {
+		s := f(e.errs[i])
+		m[s] = append(m[s], e.errs[i])
+	}
+	return m
+}
+
+// HighestSeverity method returns the highest severity of the errors in the current
+// error list. If the list is empty, SeverityNone is returned.
+func (e *Errors) HighestSeverity() Severity {
+	f := getSeverityFunc()
+	r := SeverityNone
+	for i, j := 0, len(e.errs); i < j; i++ {
+		if s := f(e.errs[i]); s > r {
+			r = s
+		}
+	}
+	return r
+}
@@ -0,0 +1,103 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSeverity_String(t *testing.T) {
+	items := map[Severity]string{
+		SeverityNone:     "none",
+		SeverityInfo:     "info",
+		SeverityWarning:  "warning",
+		SeverityError:    "error",
+		SeverityCritical: "critical",
+		Severity(-1):     "unknown",
+	}
+	for s, want := range items {
+		if got := s.String(); got != want {
+			t.Fatalf("Severity.String(): %s", got)
+		}
+	}
+}
+
+func TestErrors_BySeverity(t *testing.T) {
+	errs := new(Errors)
+	if s := errs.HighestSeverity(); s != SeverityNone {
+		t.Fatalf("Errors.HighestSeverity(): %s", s)
+	}
+	if m := errs.BySeverity(); len(m) != 0 {
+		t.Fatalf("Errors.BySeverity(): %v", m)
+	}
+
+	errs.Add(errors.New("test1"))
+	errs.Add(SafeCall(func() error { panic("test2") }))
+	errs.Add(errors.New("test3"))
+
+	m := errs.BySeverity()
+	if len(m[SeverityError]) != 2 || len(m[SeverityCritical]) != 1 {
+		t.Fatalf("Errors.BySeverity(): %v", m)
+	}
+	if s := errs.HighestSeverity(); s != SeverityCritical {
+		t.Fatalf("Errors.HighestSeverity(): %s", s)
+	}
+}
+
+func TestDefaultSeverityFunc(t *testing.T) {
+	pe := SafeCall(func() error { panic("test") })
+	items := []struct {
+		Err  error
+		Want Severity
+	}{
+		{errors.New("test"), SeverityError},
+		{pe, SeverityCritical},
+		{fmt.Errorf("db: %w", pe), SeverityCritical},
+	}
+	for i, item := range items {
+		if got := DefaultSeverityFunc(item.Err); got != item.Want {
+			t.Fatalf("DefaultSeverityFunc(): [%d] %s", i, got)
+		}
+	}
+}
+
+func TestSetSeverityFunc(t *testing.T) {
+	defer SetSeverityFunc(nil)
+
+	info := errors.New("info")
+	SetSeverityFunc(func(err error) Severity {
+		if err == info {
+			return SeverityInfo
+		}
+		return SeverityWarning
+	})
+
+	errs := new(Errors)
+	errs.Add(info)
+	if s := errs.HighestSeverity(); s != SeverityInfo {
+		t.Fatalf("Errors.HighestSeverity(): %s", s)
+	}
+	errs.Add(errors.New("test"))
+	if s := errs.HighestSeverity(); s != SeverityWarning {
+		t.Fatalf("Errors.HighestSeverity(): %s", s)
+	}
+
+	SetSeverityFunc(nil)
+	if s := errs.HighestSeverity(); s != SeverityError {
+		t.Fatalf("Errors.HighestSeverity(): %s", s)
+	}
+}
@@ -0,0 +1,68 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package runner
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// ForwardSignals function relays the given operating system signals to the given
+// process group, which is useful when the application is PID 1 in a container and
+// must propagate signals like SIGTERM to its child processes. The returned Closeable
+// stops forwarding. The errors of forwarding are logged by the logger set by the
+// SetSignalLogger function.
+// Listening to a signal disables its default action, so a forwarded exit signal,
+// such as SIGTERM, exits the application only if the application also waits for it,
+// for example, by the Runner.Wait method or the GetSystemExitChan function, otherwise
+// it is only forwarded. At least one signal must be given, otherwise this function
+// panics, because listening to all the signals would also forward the signals used
+// internally by the Go runtime.
+// This function is only available on Unix platforms.
+func ForwardSignals(pgid int, sigs ...os.Signal) Closeable {
+	if len(sigs) == 0 {
+		panic("ForwardSignals(): at least one signal must be given")
+	}
+	c := make(chan os.Signal, len(sigs)+1)
+	stop := make(chan struct{})
+	signal.Notify(c, sigs...)
+
+	go func() {
+		for {
+			select {
+			case sig := <-c:
+				s, ok := sig.(syscall.Signal)
+				if !ok {
+					continue
+				}
+				// The negative pid sends the signal to the process group.
+				if err := syscall.Kill(-pgid, s); err != nil {
+					getSignalLogger().Printf("runner: forward signal %s to process group %d: %s", sig, pgid, err)
+				}
+			case <-stop:
+				return
+			}
+		}
+	}()
+
+	return NewSafeCloseable(CloseableFunc(func() {
+		signal.Stop(c)
+		close(stop)
+	}))
+}
@@ -0,0 +1,64 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package runner
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestForwardSignals(t *testing.T) {
+	cmd := exec.Command("sh", "-c", `trap "exit 0" USR1; while true; do sleep 0.01; done`)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	if err := cmd.Start(); err != nil {
+		t.Fatalf("ForwardSignals(): %s", err)
+	}
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	c := ForwardSignals(cmd.Process.Pid, syscall.SIGUSR1)
+	defer c.Close()
+
+	// Give the shell some time to install the trap.
+	time.Sleep(time.Millisecond * 100)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGUSR1); err != nil {
+		t.Fatalf("ForwardSignals(): %s", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ForwardSignals(): %s", err)
+		}
+	case <-time.After(time.Second * 5):
+		_ = cmd.Process.Kill()
+		t.Fatal("ForwardSignals(): signal not forwarded")
+	}
+	c.Close()
+}
+
+func TestForwardSignals_NoSignal(t *testing.T) {
+	defer func() {
+		if v := recover(); v == nil {
+			t.Fatal("ForwardSignals(): no panic")
+		}
+	}()
+	ForwardSignals(os.Getpid())
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"sync"
+)
+
+// NewSingleFlightTask creates a task that coalesces the concurrent executions of
+// the given task. If the Execute method is called while a previous execution is
+// in progress, the caller waits for the in-progress execution and shares its result
+// instead of executing the given task again. The Shutdown method is not coalesced.
+// The other interfaces of the given task, such as NamedTask, PhasedTask, ContextTask
+// and RunnerAwareTask, are still used by the runner.
+func NewSingleFlightTask(t Task) Task {
+	return &singleFlightTask{task: t}
+}
+
+// The singleFlightTask type is the task wrapper that coalesces concurrent executions.
+type singleFlightTask struct {
+	task  Task
+	mutex sync.Mutex
+	call  *singleFlightCall
+}
+
+// The singleFlightCall type is the in-progress execution of the single flight task.
+type singleFlightCall struct {
+	done chan struct{}
+	err  error
+	// The number of the callers waiting for the current execution.
+	dups int
+}
+
+// Returns the wrapped task.
+func (t *singleFlightTask) unwrap() []Task {
+	return []Task{t.task}
+}
+
+// Execute method executes the wrapped task, or waits for the in-progress execution.
+func (t *singleFlightTask) Execute() error {
+	return t.do(t.task.Execute)
+}
+
+// Executes the wrapped task with the given context and runner, or waits for the
+// in-progress execution.
+func (t *singleFlightTask) executeWith(ctx context.Context, r TaskRunner) error {
+	return t.do(func() error { return callExecute(ctx, t.task, r) })
+}
+
+// Calls the given execute function, or waits for the in-progress execution.
+func (t *singleFlightTask) do(execute func() error) error {
+	t.mutex.Lock()
+	if c := t.call; c != nil {
+		c.dups++
+		t.mutex.Unlock()
+		<-c.done
+		return c.err
+	}
+	c := &singleFlightCall{done: make(chan struct{})}
+	t.call = c
+	t.mutex.Unlock()
+
+	c.err = SafeCall(execute)
+
+	t.mutex.Lock()
+	t.call = nil
+	t.mutex.Unlock()
+	close(c.done)
+	return c.err
+}
+
+// Shutdown method shuts down the wrapped task.
+func (t *singleFlightTask) Shutdown() error {
+	return t.task.Shutdown()
+}
+
+// ShutdownContext method shuts down the wrapped task with the given context.
+func (t *singleFlightTask) ShutdownContext(ctx context.Context) error {
+	return callShutdown(ctx, t.task)
+}
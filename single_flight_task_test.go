@@ -0,0 +1,116 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewSingleFlightTask(t *testing.T) {
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	want := errors.New("test")
+	task := NewSingleFlightTask(NewTaskFromFunc(func() error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+		}
+		<-release
+		return want
+	}))
+
+	var wg sync.WaitGroup
+	errs := make([]error, 10)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		errs[0] = task.Execute()
+	}()
+	<-started
+	for i := 1; i < len(errs); i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = task.Execute()
+		}(i)
+	}
+	close(release)
+	wg.Wait()
+
+	// The late callers may start a new flight after the first one completes.
+	if n := atomic.LoadInt32(&calls); n < 1 || n > int32(len(errs)) {
+		t.Fatalf("NewSingleFlightTask(): %d", n)
+	}
+	for i, err := range errs {
+		if err != want {
+			t.Fatalf("NewSingleFlightTask(): [%d] %v", i, err)
+		}
+	}
+
+	// The coalesced callers share the result of the in-progress execution.
+	atomic.StoreInt32(&calls, 0)
+	block := make(chan struct{})
+	entered := make(chan struct{})
+	task = NewSingleFlightTask(NewTaskFromFunc(func() error {
+		atomic.AddInt32(&calls, 1)
+		close(entered)
+		<-block
+		return nil
+	}))
+	done := make(chan error)
+	go func() { done <- task.Execute() }()
+	<-entered
+	go func() { done <- task.Execute() }()
+	for sf := task.(*singleFlightTask); ; {
+		sf.mutex.Lock()
+		dups := sf.call.dups
+		sf.mutex.Unlock()
+		if dups > 0 {
+			break
+		}
+		runtime.Gosched()
+	}
+	close(block)
+	if err1, err2 := <-done, <-done; err1 != nil || err2 != nil {
+		t.Fatalf("NewSingleFlightTask(): %v %v", err1, err2)
+	}
+	if n := atomic.LoadInt32(&calls); n != 1 {
+		t.Fatalf("NewSingleFlightTask(): %d", n)
+	}
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("NewSingleFlightTask(): %s", err)
+	}
+}
+
+func TestNewSingleFlightTask_Transparent(t *testing.T) {
+	r := New()
+
+	var got TaskRunner
+	r.MustRun(NewSingleFlightTask(&testRunnerAwareTask{
+		Task:    NewTaskFromFunc(nil),
+		execute: func(r TaskRunner) error { got = r; return nil },
+	}))
+	if got == nil {
+		t.Fatal("NewSingleFlightTask(): runner not forwarded")
+	}
+	r.MustRun(NewSingleFlightTask(NewNamedTaskFromFunc("db", nil, func() error { return errors.New("test") })))
+	if err := r.Exit(); err == nil || err.Error() != "db: test" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"time"
+)
+
+// State defines the lifecycle state of the runner.
+type State int
+
+// These are the lifecycle states of the runner, the runner moves through them in
+// order, and the Reset method moves the exited runner back to StateStarting.
+const (
+	// StateStarting means that no task has been registered.
+	StateStarting State = iota
+	// StateRunning means that at least one task has been registered.
+	StateRunning
+	// StateExiting means that the runner is shutting down the tasks.
+	StateExiting
+	// StateExited means that the runner has exited.
+	StateExited
+)
+
+// String returns the name of the current state.
+func (s State) String() string {
+	switch s {
+	case StateStarting:
+		return "starting"
+	case StateRunning:
+		return "running"
+	case StateExiting:
+		return "exiting"
+	case StateExited:
+		return "exited"
+	}
+	return "unknown"
+}
+
+// RunnerState is a snapshot of the lifecycle state of a runner.
+type RunnerState struct {
+	// State is the lifecycle state of the runner.
+	State State `json:"state"`
+
+	// Tasks is the number of the registered tasks that have not been shut down.
+	Tasks int `json:"tasks"`
+
+	// ExitedAt is the time when the runner exited, zero if the runner has not exited.
+	ExitedAt time.Time `json:"exited_at"`
+}
+
+// State method returns the snapshot of the lifecycle state of the current runner.
+// Unlike the other methods, it never waits for the exit in progress, so it can be
+// called by the health checks at any time.
+func (r *runner) State() RunnerState {
+	r.stateMutex.Lock()
+	defer r.stateMutex.Unlock()
+	return r.state
+}
+
+// Updates the lifecycle state of the current runner with the given function.
+func (r *runner) setState(update func(*RunnerState)) {
+	r.stateMutex.Lock()
+	update(&r.state)
+	r.stateMutex.Unlock()
+}
+
+// Registers the given executed task into the current runner, the mutex must be held,
+// and the events must be flushed after the mutex is released.
+func (r *runner) register(t Task) {
+	r.tasks = append(r.tasks, t)
+	n := len(r.tasks)
+	r.setState(func(s *RunnerState) {
+		s.State, s.Tasks = StateRunning, n
+	})
+	r.emitLocked(EventTaskRun, t, nil)
+}
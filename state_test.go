@@ -0,0 +1,79 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestState_String(t *testing.T) {
+	items := map[State]string{
+		StateStarting: "starting",
+		StateRunning:  "running",
+		StateExiting:  "exiting",
+		StateExited:   "exited",
+		State(-1):     "unknown",
+	}
+	for s, want := range items {
+		if got := s.String(); got != want {
+			t.Fatalf("State.String(): %s", got)
+		}
+	}
+}
+
+func TestRunner_State(t *testing.T) {
+	r := New()
+	if s := r.State(); s != (RunnerState{}) {
+		t.Fatalf("Runner.State(): %+v", s)
+	}
+
+	r.MustRun(NewTaskFromFunc(nil, nil))
+	r.MustRun(NewTaskFromFunc(nil, nil))
+	if s := r.State(); s.State != StateRunning || s.Tasks != 2 || !s.ExitedAt.IsZero() {
+		t.Fatalf("Runner.State(): %+v", s)
+	}
+
+	// The state can be read while the runner is exiting.
+	release := make(chan struct{})
+	r.MustRun(NewTaskFromFunc(nil, func() error {
+		<-release
+		return nil
+	}))
+	go func() {
+		for r.State().State != StateExiting {
+			time.Sleep(time.Millisecond)
+		}
+		close(release)
+	}()
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	s := r.State()
+	if s.State != StateExited || s.Tasks != 0 || s.ExitedAt.IsZero() {
+		t.Fatalf("Runner.State(): %+v", s)
+	}
+	if _, err := json.Marshal(s); err != nil {
+		t.Fatalf("Runner.State(): %s", err)
+	}
+
+	if err := r.Reset(); err != nil {
+		t.Fatalf("Runner.Reset(): %s", err)
+	}
+	if s := r.State(); s != (RunnerState{}) {
+		t.Fatalf("Runner.State(): %+v", s)
+	}
+}
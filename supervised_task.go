@@ -0,0 +1,150 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RestartPolicy defines the restart policy of the supervised task.
+type RestartPolicy struct {
+	// MaxRestarts is the maximum number of restarts, zero or a negative value means
+	// that the task is always restarted until the runner exits.
+	MaxRestarts int
+
+	// Backoff returns the duration to wait before the given restart attempt (starting
+	// from 1), it can be nil, which means restarting immediately.
+	Backoff func(attempt int) time.Duration
+
+	// OnRestart is called before each restart with the restart attempt (starting
+	// from 1) and the error of the previous execution, it can be nil.
+	OnRestart func(attempt int, err error)
+
+	// OnGiveUp is called when the task fails after the maximum number of restarts,
+	// with an *Errors containing the errors of all the executions, it can be nil.
+	// It is called with panic protection, and the panic is logged by the runner.
+	OnGiveUp func(err error)
+}
+
+// RunSupervised method registers the given task and executes it asynchronously
+// under supervision. If the execution fails (returns an error or panics), the task
+// is restarted according to the given policy, until it returns nil, the policy gives
+// up, or the task is shut down. The task is registered immediately, so it is always
+// shut down when the runner exits. If the runner has exited, ErrExited is returned.
+func (r *runner) RunSupervised(t Task, policy RestartPolicy) error {
+	defer r.flushEvents()
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if r.closed() {
+		return ErrExited
+	}
+
+	st := &supervisedTask{task: r.decorate(t), runner: r, logger: r.logger, stop: make(chan struct{})}
+	r.register(st)
+	goManaged(func() { st.supervise(policy) }, nil)
+	return nil
+}
+
+// The supervisedTask type is the task wrapper that restarts the failed executions.
+// The declarative interfaces of the wrapped task, such as NamedTask and PhasedTask,
+// are looked up through the wrapper, so the wrapper is transparent to the runner.
+type supervisedTask struct {
+	task   Task
+	runner TaskRunner
+	logger Logger
+	stop   chan struct{}
+
+	// Once the task is stopped, no new execution is admitted.
+	mutex   sync.Mutex
+	stopped bool
+}
+
+// Returns the wrapped task.
+func (t *supervisedTask) unwrap() []Task {
+	return []Task{t.task}
+}
+
+// Execute method executes the supervised task once.
+// If the task has been shut down, it is not executed anymore.
+func (t *supervisedTask) Execute() error {
+	return t.executeWith(context.Background(), t.runner)
+}
+
+// Executes the supervised task once with the given context and runner.
+// The stop state is checked while the mutex is held, and the Shutdown method sets it
+// while holding the mutex, so no execution is admitted after the Shutdown method is
+// called. An execution admitted just before that may enter the wrapped task after its
+// Shutdown method is called, so, like any long-running task, the wrapped task must
+// not block in the Execute method after it has been shut down.
+func (t *supervisedTask) executeWith(ctx context.Context, r TaskRunner) error {
+	t.mutex.Lock()
+	stopped := t.stopped
+	t.mutex.Unlock()
+	if stopped {
+		return nil
+	}
+	return callExecute(ctx, t.task, r)
+}
+
+// Shutdown method stops the supervision and shuts down the supervised task.
+func (t *supervisedTask) Shutdown() error {
+	return t.ShutdownContext(context.Background())
+}
+
+// ShutdownContext method stops the supervision and shuts down the supervised task
+// with the given context if it implements the ContextTask interface.
+func (t *supervisedTask) ShutdownContext(ctx context.Context) error {
+	t.mutex.Lock()
+	if !t.stopped {
+		t.stopped = true
+		close(t.stop)
+	}
+	t.mutex.Unlock()
+	return callShutdown(ctx, t.task)
+}
+
+// Executes the supervised task and restarts it according to the given policy.
+func (t *supervisedTask) supervise(policy RestartPolicy) {
+	var attempts int
+	if policy.MaxRestarts > 0 {
+		attempts = policy.MaxRestarts + 1
+	}
+	var last error
+	attempt := 0
+	err := RetryUntil(t.stop, attempts, policy.Backoff, func() error {
+		if attempt > 0 && policy.OnRestart != nil {
+			policy.OnRestart(attempt, last)
+		}
+		attempt++
+		last = SafeCall(t.Execute)
+		return last
+	})
+	if err == nil || policy.OnGiveUp == nil {
+		return
+	}
+	// The supervision stopped by the shutdown is not a failure.
+	select {
+	case <-t.stop:
+	default:
+		if e := SafeCall(func() error {
+			policy.OnGiveUp(err)
+			return nil
+		}); e != nil {
+			t.logger.Printf("runner: supervised task %s give up handler panic: %s", taskName(t.task), e)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunner_RunSupervised(t *testing.T) {
+	r := New()
+
+	var executed, restarts int32
+	done := make(chan struct{})
+	err := r.RunSupervised(NewTaskFromFunc(func() error {
+		switch atomic.AddInt32(&executed, 1) {
+		case 1:
+			return errors.New("test")
+		case 2:
+			panic("test")
+		default:
+			close(done)
+			return nil
+		}
+	}, nil), RestartPolicy{
+		MaxRestarts: 3,
+		OnRestart: func(attempt int, err error) {
+			if n := atomic.AddInt32(&restarts, 1); int(n) != attempt || err == nil {
+				t.Errorf("RestartPolicy.OnRestart(): %d %v", attempt, err)
+			}
+		},
+		OnGiveUp: func(err error) { t.Errorf("RestartPolicy.OnGiveUp(): %v", err) },
+	})
+	if err != nil {
+		t.Fatalf("Runner.RunSupervised(): %s", err)
+	}
+
+	<-done
+	if n := atomic.LoadInt32(&restarts); n != 2 {
+		t.Fatalf("Runner.RunSupervised(): restarts %d", n)
+	}
+	if tasks := r.Tasks(); len(tasks) != 1 || taskName(tasks[0]) != "*runner.funcTask" {
+		t.Fatalf("Runner.Tasks(): %v", tasks)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if err := r.RunSupervised(NewTaskFromFunc(nil, nil), RestartPolicy{}); err != ErrExited {
+		t.Fatalf("Runner.RunSupervised(): %v", err)
+	}
+}
+
+func TestRunner_RunSupervised_GiveUp(t *testing.T) {
+	r := New()
+
+	var executed int32
+	result := make(chan error, 1)
+	err := r.RunSupervised(NewTaskFromFunc(func() error {
+		atomic.AddInt32(&executed, 1)
+		return errors.New("test")
+	}, nil), RestartPolicy{
+		MaxRestarts: 2,
+		Backoff:     func(int) time.Duration { return time.Millisecond },
+		OnGiveUp:    func(err error) { result <- err },
+	})
+	if err != nil {
+		t.Fatalf("Runner.RunSupervised(): %s", err)
+	}
+
+	err = <-result
+	if es, ok := err.(*Errors); !ok || es.Len() != 3 {
+		t.Fatalf("RestartPolicy.OnGiveUp(): %v", err)
+	}
+	if n := atomic.LoadInt32(&executed); n != 3 {
+		t.Fatalf("Runner.RunSupervised(): executed %d", n)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+}
+
+func TestRunner_RunSupervised_GiveUpPanic(t *testing.T) {
+	logger := new(testLogger)
+	r := New(WithLogger(logger))
+
+	err := r.RunSupervised(NewNamedTaskFromFunc("worker", func() error {
+		return errors.New("test")
+	}, nil), RestartPolicy{
+		MaxRestarts: 1,
+		OnGiveUp:    func(error) { panic("test") },
+	})
+	if err != nil {
+		t.Fatalf("Runner.RunSupervised(): %s", err)
+	}
+
+	// The panic of the handler is logged instead of escaping the supervisor.
+	deadline := time.Now().Add(time.Second * 5)
+	for len(logger.Messages()) == 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("RestartPolicy.OnGiveUp(): panic not logged")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if ms := logger.Messages(); !strings.HasPrefix(ms[0], "runner: supervised task worker give up handler panic: ") {
+		t.Fatalf("RestartPolicy.OnGiveUp(): %v", ms)
+	}
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+}
+
+func TestRunner_RunSupervised_Exit(t *testing.T) {
+	r := New()
+
+	var executed int32
+	started := make(chan struct{}, 1)
+	err := r.RunSupervised(NewTaskFromFunc(func() error {
+		atomic.AddInt32(&executed, 1)
+		select {
+		case started <- struct{}{}:
+		default:
+		}
+		return errors.New("test")
+	}, nil), RestartPolicy{
+		Backoff:  func(int) time.Duration { return time.Hour },
+		OnGiveUp: func(err error) { t.Errorf("RestartPolicy.OnGiveUp(): %v", err) },
+	})
+	if err != nil {
+		t.Fatalf("Runner.RunSupervised(): %s", err)
+	}
+
+	<-started
+	if err := r.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	time.Sleep(time.Millisecond * 10)
+	if n := atomic.LoadInt32(&executed); n != 1 {
+		t.Fatalf("Runner.RunSupervised(): executed %d", n)
+	}
+}
+
+func TestRunner_RunSupervised_Transparent(t *testing.T) {
+	// The supervised task is not named, so the errors are not annotated.
+	r := New()
+	if err := r.RunSupervised(NewTaskFromFunc(nil, func() error { return errors.New("test") }), RestartPolicy{}); err != nil {
+		t.Fatalf("Runner.RunSupervised(): %s", err)
+	}
+	if err := r.Exit(); err == nil || err.Error() != "test" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+
+	// The name and the phase of the wrapped task are used.
+	var ss []string
+	r = New(WithPhases("first"))
+	if err := r.RunSupervised(NewNamedTaskFromFunc("db", nil, func() error {
+		ss = append(ss, "db")
+		return errors.New("test")
+	}), RestartPolicy{}); err != nil {
+		t.Fatalf("Runner.RunSupervised(): %s", err)
+	}
+	if err := r.RunSupervised(&testPhasedTask{Task: NewTaskFromFunc(nil, func() error {
+		ss = append(ss, "first")
+		return nil
+	}), phase: "first"}, RestartPolicy{}); err != nil {
+		t.Fatalf("Runner.RunSupervised(): %s", err)
+	}
+	if err := r.Exit(); err == nil || err.Error() != "db: test" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+	if got := strings.Join(ss, ","); got != "first,db" {
+		t.Fatalf("Runner.Exit(): %s", got)
+	}
+}
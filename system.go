@@ -25,6 +25,14 @@ var (
 	systemExitChan = make(chan struct{})
 	systemExitOnce = new(sync.Once)
 	systemWaitOnce = new(sync.Once)
+
+	// The handled signals are routed by their own coroutine, so registering a handler
+	// does not listen to the exit signals.
+	systemRouteOnce             = new(sync.Once)
+	systemRouteChan             = make(chan os.Signal, 1)
+	systemSignalMutex           = new(sync.RWMutex)
+	systemSignalHandlers        = make(map[os.Signal]func() error)
+	systemSignalLogger   Logger = stdLogger{}
 )
 
 // GetSystemExitChan function returns the system exit signal channel.
@@ -37,21 +45,95 @@ func GetSystemExitChan() <-chan struct{} {
 // the exit signal of the operating system is captured.
 func WaitSystemExit() { <-GetSystemExitChan() }
 
+// OnSignalFunc function registers a handler for the given operating system signal.
+// When the registered signal is captured, the handler is called instead of closing
+// the system exit signal channel, so signals like SIGHUP can be used to reload the
+// application. Signals without a handler (SIGTERM and SIGINT by default) still exit.
+// A nil handler means that the signal is ignored, even if it is an exit signal.
+// The handler is called with panic protection, and the returned error is logged by
+// the logger set by the SetSignalLogger function.
+// Registering a handler for the same signal again replaces the previous handler.
+// Only the given signal is listened, the exit signals are listened only after the
+// system exit signal channel is used, such as by the Runner.Wait method, so the
+// default behavior of the other signals is not changed.
+func OnSignalFunc(sig os.Signal, handler func() error) {
+	systemSignalMutex.Lock()
+	systemSignalHandlers[sig] = handler
+	systemSignalMutex.Unlock()
+
+	signal.Notify(systemRouteChan, sig)
+	systemRouteOnce.Do(func() { go routeSystemSignals() })
+}
+
+// SetSignalLogger function sets the logger of the errors returned by the signal
+// handlers registered by the OnSignalFunc function. By default, the errors are
+// written to the standard logger of the log package. A runner can share its logger,
+// see the WithLogger option.
+func SetSignalLogger(logger Logger) {
+	systemSignalMutex.Lock()
+	systemSignalLogger = logger
+	systemSignalMutex.Unlock()
+}
+
+// Returns the logger set by the SetSignalLogger function.
+func getSignalLogger() Logger {
+	systemSignalMutex.RLock()
+	defer systemSignalMutex.RUnlock()
+	return systemSignalLogger
+}
+
 // Start a coroutine to run the waitSystemExitSignal function.
 func doWaitSystemExitSignal() {
 	go waitSystemExitSignal()
 }
 
 // Wait the exit signal of the operating system.
+// The exit signals that have a handler are left to the routing coroutine, which
+// keeps routing them after the exit.
 func waitSystemExitSignal() {
-	c := make(chan os.Signal)
+	c := make(chan os.Signal, 1)
 	signal.Notify(c, syscall.SIGTERM, syscall.SIGINT)
 	defer signal.Stop(c)
 
-	select {
-	case <-c:
+	for sig := range c {
+		if hasSystemSignalHandler(sig) {
+			continue
+		}
 		systemExitOnce.Do(func() { close(systemExitChan) })
-	case <-systemExitChan:
-		// Do nothing
+		return
+	}
+}
+
+// Route the signals that have a handler to the handler.
+func routeSystemSignals() {
+	for sig := range systemRouteChan {
+		dispatchSystemSignal(sig)
+	}
+}
+
+// Determines whether the given signal has a registered handler.
+func hasSystemSignalHandler(sig os.Signal) bool {
+	systemSignalMutex.RLock()
+	_, found := systemSignalHandlers[sig]
+	systemSignalMutex.RUnlock()
+	return found
+}
+
+// Dispatch the given signal to the registered handler.
+// If there is no handler for the given signal, returns false.
+func dispatchSystemSignal(sig os.Signal) bool {
+	systemSignalMutex.RLock()
+	handler, found := systemSignalHandlers[sig]
+	logger := systemSignalLogger
+	systemSignalMutex.RUnlock()
+
+	if !found {
+		return false
+	}
+	if handler != nil {
+		if err := SafeCall(handler); err != nil {
+			logger.Printf("runner: signal %s handler: %s", sig, err)
+		}
 	}
+	return true
 }
@@ -0,0 +1,72 @@
+// Copyright 2020 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build !js && !plan9
+// +build !js,!plan9
+
+package runner
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+func TestOnSignalFunc(t *testing.T) {
+	defer func() {
+		systemSignalMutex.Lock()
+		delete(systemSignalHandlers, syscall.SIGHUP)
+		systemSignalMutex.Unlock()
+	}()
+
+	logger := new(testLogger)
+	SetSignalLogger(logger)
+	defer SetSignalLogger(stdLogger{})
+
+	var n int
+	OnSignalFunc(syscall.SIGHUP, func() error {
+		n++
+		return errors.New("test")
+	})
+
+	if !dispatchSystemSignal(syscall.SIGHUP) {
+		t.Fatal("dispatchSystemSignal(): false")
+	}
+	if n != 1 {
+		t.Fatalf("OnSignalFunc(): %d", n)
+	}
+	if ms := logger.Messages(); len(ms) != 1 || ms[0] != "runner: signal hangup handler: test" {
+		t.Fatalf("SetSignalLogger(): %v", ms)
+	}
+
+	// The nil handler ignores the signal.
+	OnSignalFunc(syscall.SIGHUP, nil)
+	if !dispatchSystemSignal(syscall.SIGHUP) || !hasSystemSignalHandler(syscall.SIGHUP) {
+		t.Fatal("dispatchSystemSignal(): false")
+	}
+
+	OnSignalFunc(syscall.SIGHUP, func() error { panic("test") })
+	if !dispatchSystemSignal(syscall.SIGHUP) {
+		t.Fatal("dispatchSystemSignal(): false")
+	}
+	if dispatchSystemSignal(syscall.SIGTERM) {
+		t.Fatal("dispatchSystemSignal(): true")
+	}
+
+	select {
+	case <-GetSystemExitChan():
+		t.Fatal("GetSystemExitChan(): closed")
+	default:
+	}
+}
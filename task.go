@@ -14,6 +14,12 @@
 
 package runner
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 // Task interface defines the task units that the runner can run.
 type Task interface {
 	// Execute method is the entry point for the task to run.
@@ -25,6 +31,218 @@ type Task interface {
 	Shutdown() error
 }
 
+// ContextTask interface defines the task that can receive the shutdown context.
+// When the runner exits through the Runner.ExitContext method, the ShutdownContext
+// method is called instead of the Shutdown method.
+type ContextTask interface {
+	Task
+
+	// ShutdownContext method is the method to exit the task with the given context.
+	// The deadline of the given context is the remaining shutdown budget of the runner.
+	ShutdownContext(context.Context) error
+}
+
+// ExecuteContextTask interface defines the task that can receive the execution context.
+// When the task is run through the Runner.RunContext method, the ExecuteContext
+// method is called instead of the Execute method.
+type ExecuteContextTask interface {
+	Task
+
+	// ExecuteContext method is the entry point for the task to run with the given
+	// context, the task should abort the execution when the context is done.
+	ExecuteContext(context.Context) error
+}
+
+// RunnerAwareTask interface defines the task that can receive the runner executing
+// it, so it can register its sub-tasks into the same runner. When the task is run by
+// a runner, the ExecuteWithRunner method is called instead of the Execute method.
+// The sub-tasks registered during the execution are registered before the task
+// itself, so they are shut down after the task.
+type RunnerAwareTask interface {
+	Task
+
+	// ExecuteWithRunner method is the entry point for the task to run with the
+	// runner executing it.
+	ExecuteWithRunner(TaskRunner) error
+}
+
+// TaskRunner interface defines the runner given to the RunnerAwareTask, which can
+// only run the sub-tasks of the task. The runner may hold its lock during the
+// execution of the task, so the other methods of the Runner are not exposed.
+type TaskRunner interface {
+	// Run method executes the given task instance synchronously.
+	Run(Task) error
+
+	// RunContext method is like Run, but the given context is passed to the task that
+	// implements the ExecuteContextTask interface.
+	RunContext(context.Context, Task) error
+}
+
+// Executes the given task with the given context and panic protection, the given
+// runner is passed to the task that implements the RunnerAwareTask interface.
+func executeTask(ctx context.Context, t Task, r TaskRunner) error {
+	return SafeCall(func() error { return callExecute(ctx, t, r) })
+}
+
+// Calls the entry point of the given task that accepts the given context or runner.
+func callExecute(ctx context.Context, t Task, r TaskRunner) error {
+	switch tt := t.(type) {
+	case wrapperTask:
+		return tt.executeWith(ctx, r)
+	case RunnerAwareTask:
+		return tt.ExecuteWithRunner(r)
+	case ExecuteContextTask:
+		return tt.ExecuteContext(ctx)
+	}
+	return t.Execute()
+}
+
+// Shuts down the given task with the given context if it implements the ContextTask
+// interface, otherwise the context is ignored.
+func callShutdown(ctx context.Context, t Task) error {
+	if ct, ok := t.(ContextTask); ok {
+		return ct.ShutdownContext(ctx)
+	}
+	return t.Shutdown()
+}
+
+// The wrapperTask interface is implemented by the task wrappers of this package.
+// The declarative interfaces of the wrapped tasks (NamedTask, OptionalTask, PhasedTask,
+// TimeoutTask and WeightedTask) are looked up through the wrapper, and the wrapper
+// forwards the execution context and the runner to the wrapped task, so the task
+// behaves as if it is run directly. The wrappers also implement the ContextTask
+// interface to forward the shutdown context.
+type wrapperTask interface {
+	ContextTask
+
+	// Returns the wrapped tasks in the lookup order.
+	unwrap() []Task
+
+	// Executes the wrapped task with the given context and runner.
+	executeWith(ctx context.Context, r TaskRunner) error
+}
+
+// Looks up the given task and the tasks wrapped by it in depth-first order, and
+// returns the first task accepted by the given function, or nil if none is accepted.
+func lookupTask(t Task, accept func(Task) bool) Task {
+	if accept(t) {
+		return t
+	}
+	if w, ok := t.(wrapperTask); ok {
+		for _, inner := range w.unwrap() {
+			if found := lookupTask(inner, accept); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the task that implements the NamedTask interface in the given task.
+func asNamedTask(t Task) (NamedTask, bool) {
+	n, ok := lookupTask(t, func(t Task) bool { _, ok := t.(NamedTask); return ok }).(NamedTask)
+	return n, ok
+}
+
+// Returns the task that implements the OptionalTask interface in the given task.
+func asOptionalTask(t Task) (OptionalTask, bool) {
+	o, ok := lookupTask(t, func(t Task) bool { _, ok := t.(OptionalTask); return ok }).(OptionalTask)
+	return o, ok
+}
+
+// Returns the task that implements the PhasedTask interface in the given task.
+func asPhasedTask(t Task) (PhasedTask, bool) {
+	p, ok := lookupTask(t, func(t Task) bool { _, ok := t.(PhasedTask); return ok }).(PhasedTask)
+	return p, ok
+}
+
+// Returns the task that implements the TimeoutTask interface in the given task.
+func asTimeoutTask(t Task) (TimeoutTask, bool) {
+	tt, ok := lookupTask(t, func(t Task) bool { _, ok := t.(TimeoutTask); return ok }).(TimeoutTask)
+	return tt, ok
+}
+
+// Returns the task that implements the WeightedTask interface in the given task.
+func asWeightedTask(t Task) (WeightedTask, bool) {
+	w, ok := lookupTask(t, func(t Task) bool { _, ok := t.(WeightedTask); return ok }).(WeightedTask)
+	return w, ok
+}
+
+// OptionalTask interface defines the best-effort task.
+// If the Optional method returns true and the execution of the task fails (returns
+// an error or panics), the runner logs the error and ignores the task instead of
+// returning the error, the task is not registered and will never be shut down.
+type OptionalTask interface {
+	Task
+
+	// Optional method determines whether the current task is optional.
+	Optional() bool
+}
+
+// Determines whether the given task is optional.
+func isOptionalTask(t Task) bool {
+	if o, ok := asOptionalTask(t); ok {
+		return o.Optional()
+	}
+	return false
+}
+
+// PhasedTask interface defines the task that belongs to a shutdown phase.
+// The shutdown phases are set by the WithPhases option.
+type PhasedTask interface {
+	Task
+
+	// Phase method returns the name of the shutdown phase of the current task.
+	Phase() string
+}
+
+// TimeoutTask interface defines the task that has a shutdown timeout.
+// If the shutdown of the task does not return within the timeout, the runner stops
+// waiting for it, records the ErrShutdownTimeout error for it, and continues to shut
+// down the remaining tasks. The abandoned shutdown keeps running in the background.
+type TimeoutTask interface {
+	Task
+
+	// ShutdownTimeout method returns the shutdown timeout of the current task,
+	// zero or a negative value means no timeout.
+	ShutdownTimeout() time.Duration
+}
+
+// WeightedTask interface defines the task that has a shutdown weight.
+// When the runner exits through the Runner.ExitWithTimeout method, the duration is
+// distributed to the tasks in proportion to their shutdown weights.
+type WeightedTask interface {
+	Task
+
+	// ShutdownWeight method returns the shutdown weight of the current task,
+	// the weight less than 1 is treated as 1.
+	ShutdownWeight() int
+}
+
+// NamedTask interface defines the task that has a name.
+// The name is used in the logs and errors of the runner instead of the type name.
+type NamedTask interface {
+	Task
+
+	// Name method returns the name of the current task.
+	Name() string
+}
+
+// Returns the name of the given task used in logs and errors.
+// If the task is not named, the type name of the innermost wrapped task is used.
+func taskName(t Task) string {
+	if n, ok := asNamedTask(t); ok {
+		return n.Name()
+	}
+	for {
+		w, ok := t.(wrapperTask)
+		if !ok {
+			return fmt.Sprintf("%T", t)
+		}
+		t = w.unwrap()[0]
+	}
+}
+
 // NewTaskFromFunc creates a runnable task from a given function.
 func NewTaskFromFunc(execute func() error, shutdown ...func() error) Task {
 	switch len(shutdown) {
@@ -37,6 +255,27 @@ func NewTaskFromFunc(execute func() error, shutdown ...func() error) Task {
 	}
 }
 
+// NewNamedTaskFromFunc creates a runnable task with the given name from the given
+// functions, the returned task implements the NamedTask interface.
+func NewNamedTaskFromFunc(name string, execute func() error, shutdown func() error) Task {
+	return &namedFuncTask{funcTask: funcTask{execute: execute, shutdown: shutdown}, name: name}
+}
+
+// NewTaskFromFuncWithTimeout creates a runnable task from the given functions, the
+// returned task implements the TimeoutTask interface with the given shutdown timeout.
+func NewTaskFromFuncWithTimeout(execute, shutdown func() error, timeout time.Duration) Task {
+	return &timeoutFuncTask{funcTask: funcTask{execute: execute, shutdown: shutdown}, timeout: timeout}
+}
+
+// NewCancelTask creates a task that calls the given cancel function on shutdown.
+// The Execute method of the returned task does nothing.
+func NewCancelTask(cancel context.CancelFunc) Task {
+	return &funcTask{shutdown: func() error {
+		cancel()
+		return nil
+	}}
+}
+
 // The funcTask type is used to wrap a given function into a runnable task.
 type funcTask struct {
 	execute, shutdown func() error
@@ -59,3 +298,25 @@ func (t *funcTask) Shutdown() error {
 	}
 	return t.shutdown()
 }
+
+// The timeoutFuncTask type is the function task with a shutdown timeout.
+type timeoutFuncTask struct {
+	funcTask
+	timeout time.Duration
+}
+
+// ShutdownTimeout method returns the shutdown timeout of the current task.
+func (t *timeoutFuncTask) ShutdownTimeout() time.Duration {
+	return t.timeout
+}
+
+// The namedFuncTask type is the function task with a name.
+type namedFuncTask struct {
+	funcTask
+	name string
+}
+
+// Name method returns the name of the current task.
+func (t *namedFuncTask) Name() string {
+	return t.name
+}
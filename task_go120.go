@@ -0,0 +1,31 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.20
+// +build go1.20
+
+package runner
+
+import (
+	"context"
+)
+
+// NewCancelCauseTask creates a task that calls the given cancel function with
+// the given cause on shutdown. The Execute method of the returned task does nothing.
+func NewCancelCauseTask(cancel context.CancelCauseFunc, cause error) Task {
+	return &funcTask{shutdown: func() error {
+		cancel(cause)
+		return nil
+	}}
+}
@@ -0,0 +1,43 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.20
+// +build go1.20
+
+package runner
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestNewCancelCauseTask(t *testing.T) {
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cause := errors.New("test")
+	task := NewCancelCauseTask(cancel, cause)
+
+	if err := task.Execute(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("Task.Shutdown(): %s", err)
+	}
+	if got := context.Cause(ctx); got != cause {
+		t.Fatalf("Task.Shutdown(): %v", got)
+	}
+}
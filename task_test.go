@@ -15,7 +15,9 @@
 package runner
 
 import (
+	"context"
 	"testing"
+	"time"
 )
 
 func TestNewTaskFromFunc(t *testing.T) {
@@ -47,3 +49,50 @@ func TestNewTaskFromFuncPanic(t *testing.T) {
 
 	NewTaskFromFunc(nil, nil, nil)
 }
+
+func TestNewCancelTask(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	task := NewCancelTask(cancel)
+
+	if err := task.Execute(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+	if err := ctx.Err(); err != nil {
+		t.Fatalf("Task.Execute(): %s", err)
+	}
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("Task.Shutdown(): %s", err)
+	}
+	if err := ctx.Err(); err != context.Canceled {
+		t.Fatalf("Task.Shutdown(): %v", err)
+	}
+}
+
+func TestNewTaskFromFuncWithTimeout(t *testing.T) {
+	task := NewTaskFromFuncWithTimeout(nil, nil, time.Second)
+	if d := task.(TimeoutTask).ShutdownTimeout(); d != time.Second {
+		t.Fatalf("NewTaskFromFuncWithTimeout(): %s", d)
+	}
+	if err := task.Execute(); err != nil {
+		t.Fatalf("NewTaskFromFuncWithTimeout(): %s", err)
+	}
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("NewTaskFromFuncWithTimeout(): %s", err)
+	}
+}
+
+func TestNewNamedTaskFromFunc(t *testing.T) {
+	task := NewNamedTaskFromFunc("cache", nil, nil)
+	if name := task.(NamedTask).Name(); name != "cache" {
+		t.Fatalf("NewNamedTaskFromFunc(): %s", name)
+	}
+	if name := taskName(task); name != "cache" {
+		t.Fatalf("NewNamedTaskFromFunc(): %s", name)
+	}
+	if err := task.Execute(); err != nil {
+		t.Fatalf("NewNamedTaskFromFunc(): %s", err)
+	}
+	if err := task.Shutdown(); err != nil {
+		t.Fatalf("NewNamedTaskFromFunc(): %s", err)
+	}
+}
@@ -0,0 +1,116 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package runner
+
+import (
+	"sync"
+)
+
+// TypedWaiter interface defines the waiter that receives a value from the broadcast.
+type TypedWaiter[T any] interface {
+	ReceiptableWaiter
+
+	// Value returns the value delivered by the broadcast that closes the current
+	// waiter, it should be called after the waiter is closed. If the waiter is closed
+	// by the Close method of the broadcaster, the zero value is returned.
+	Value() T
+}
+
+// TypedBroadcaster interface defines the broadcaster that delivers a value to the
+// waiters, which is a one-shot publish and subscribe primitive. Like the Broadcaster,
+// the waiters are closed in reverse order of creation, and the broadcast waits for
+// all the waiters to call the Waiter.Done method.
+type TypedBroadcaster[T any] interface {
+	// NewWaiter creates and returns a new TypedWaiter instance.
+	// The Waiter returned by this method is one-time, and once it is closed,
+	// it will always be closed. If the broadcaster is closed, then this method
+	// will always return a closed waiter with the zero value.
+	NewWaiter() TypedWaiter[T]
+
+	// Broadcast delivers the given value to all the waiters that have been created,
+	// closes them and waits for all the waiters to call the Waiter.Done method.
+	// After this method is called, the broadcaster will return to its initial state.
+	Broadcast(T)
+
+	// Close closes the current broadcaster, the waiters receive the zero value.
+	// After this method returns, the NewWaiter method will always return a closed
+	// waiter.
+	Close()
+}
+
+// NewTypedBroadcaster creates and returns a new TypedBroadcaster instance.
+func NewTypedBroadcaster[T any]() TypedBroadcaster[T] {
+	return &typedBroadcaster[T]{broadcaster: new(broadcaster), cycle: new(typedCycle[T])}
+}
+
+// The built-in implementation of the TypedBroadcaster interface.
+// The value is delivered by the cycle shared by the waiters of the same broadcast.
+type typedBroadcaster[T any] struct {
+	mutex       sync.Mutex
+	broadcaster *broadcaster
+	cycle       *typedCycle[T]
+}
+
+// The typedCycle type holds the value delivered by a broadcast.
+// The value is written before the waiters are closed, so it is safe to read it
+// after the waiter is closed.
+type typedCycle[T any] struct {
+	value T
+}
+
+// The typedWaiter type is the built-in TypedWaiter.
+type typedWaiter[T any] struct {
+	ReceiptableWaiter
+	cycle *typedCycle[T]
+}
+
+// Value returns the value delivered by the broadcast that closes the current waiter.
+func (w *typedWaiter[T]) Value() T {
+	return w.cycle.value
+}
+
+// NewWaiter creates and returns a new TypedWaiter instance.
+func (b *typedBroadcaster[T]) NewWaiter() TypedWaiter[T] {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	return &typedWaiter[T]{ReceiptableWaiter: b.broadcaster.NewWaiter(), cycle: b.cycle}
+}
+
+// Broadcast delivers the given value to all the waiters that have been created,
+// closes them and waits for all the waiters to call the Waiter.Done method.
+func (b *typedBroadcaster[T]) Broadcast(v T) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	// The closed broadcaster has no waiter, and its waiters keep the zero value.
+	if b.broadcaster.closed {
+		return
+	}
+	b.cycle.value = v
+	b.cycle = new(typedCycle[T])
+	b.broadcaster.Broadcast()
+}
+
+// Close closes the current broadcaster, the waiters receive the zero value.
+func (b *typedBroadcaster[T]) Close() {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.broadcaster.Close()
+}
@@ -0,0 +1,66 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build go1.18
+// +build go1.18
+
+package runner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTypedBroadcaster(t *testing.T) {
+	b := NewTypedBroadcaster[string]()
+
+	var ss []string
+	for i := 0; i < 2; i++ {
+		go func(name string, w TypedWaiter[string]) {
+			defer w.Done()
+			w.Wait()
+			ss = append(ss, name+":"+w.Value())
+		}(string(rune('A'+i)), b.NewWaiter())
+	}
+
+	b.Broadcast("v1")
+	if got := strings.Join(ss, "-"); got != "B:v1-A:v1" {
+		t.Fatalf("TypedBroadcaster.Broadcast(): %s", got)
+	}
+
+	// The waiters of the next broadcast receive the next value.
+	w := b.NewWaiter()
+	go w.Done()
+	b.Broadcast("v2")
+	if v := w.Value(); v != "v2" {
+		t.Fatalf("TypedWaiter.Value(): %s", v)
+	}
+
+	w = b.NewWaiter()
+	go w.Done()
+	b.Close()
+	b.Broadcast("v3")
+	if v := w.Value(); v != "" {
+		t.Fatalf("TypedWaiter.Value(): %s", v)
+	}
+	w = b.NewWaiter()
+	select {
+	case <-w.Channel():
+	default:
+		t.Fatal("TypedBroadcaster.NewWaiter(): not closed")
+	}
+	if v := w.Value(); v != "" {
+		t.Fatalf("TypedWaiter.Value(): %s", v)
+	}
+}
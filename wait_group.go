@@ -15,28 +15,108 @@
 package runner
 
 import (
+	"context"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
 // WaitGroup waits for a collection of goroutines to finish.
-// We only provide WaitGroup.Go and WaitGroup.MultiGo on the basis of sync.WaitGroup.
+// On the basis of sync.WaitGroup, it provides the methods to start the goroutines,
+// such as WaitGroup.Go and WaitGroup.MultiGo, and the methods to wait for them with
+// a deadline, such as WaitGroup.WaitTimeout and WaitGroup.WaitContext.
 // Example:
-//   var wg WaitGroup
-//   wg.Go(func() { /* do something */ })
-//   wg.Wait()
+//
+//	var wg WaitGroup
+//	wg.Go(func() { /* do something */ })
+//	wg.Wait()
+//
 // or
-//   wg.MultiGo(5, func() { /* do something */ })
-//   wg.Wait()
+//
+//	wg.MultiGo(5, func() { /* do something */ })
+//	wg.Wait()
+//
+// By default, a panic in a goroutine crashes the process. If the OnPanic field is
+// set, the panics in the goroutines of the group are recovered and reported to it.
+// The functions that can fail are run by WaitGroup.GoE, and their errors are
+// collected and returned by WaitGroup.Err after WaitGroup.Wait returns.
 type WaitGroup struct {
+	// The counters are accessed atomically, keep them at the beginning of the
+	// struct to guarantee the 64-bit alignment on 32-bit platforms.
+	launched int64
+	running  int64
+	peak     int64
+
+	// OnPanic receives the recovered value and the stack of the panicking goroutine.
+	// It should be set before any goroutine is started.
+	OnPanic func(recovered interface{}, stack []byte)
+
 	wg sync.WaitGroup
+
+	mutex  sync.Mutex
+	errs   Errors
+	panics []error
+	// The channel is closed by the shared helper goroutine after all goroutines exit.
+	done chan struct{}
 }
 
 // Go uses a goroutines to run the f function.
 func (w *WaitGroup) Go(f func()) {
 	w.wg.Add(1)
+	w.launch(1)
 	go w.do(f)
 }
 
+// GoE uses a goroutine to run the f function, and collects the error returned by it.
+// If the f function panics, the panic is recovered and collected as a PanicError.
+func (w *WaitGroup) GoE(f func() error) {
+	w.Go(func() {
+		if err := SafeCall(f); err != nil {
+			w.mutex.Lock()
+			w.errs.Add(err)
+			w.mutex.Unlock()
+		}
+	})
+}
+
+// Err returns the errors collected from the goroutines started by the GoE method.
+// If there is no error, nil is returned, and if there is only one error, the error
+// is returned directly, otherwise an *Errors is returned.
+func (w *WaitGroup) Err() error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	errs := &Errors{errs: append([]error(nil), w.errs.errs...)}
+	return errs.result()
+}
+
+// GoSafe uses a goroutine to run the f function, and if the f function panics, the
+// panic is recovered and recorded as a PanicError containing the stack of the
+// goroutine, which can be retrieved by the Panics method after the Wait method
+// returns, so a single bad item can not crash the whole batch.
+func (w *WaitGroup) GoSafe(f func()) {
+	w.Go(func() {
+		if err := SafeCallWithStack(func() error { f(); return nil }); err != nil {
+			w.mutex.Lock()
+			w.panics = append(w.panics, err)
+			w.mutex.Unlock()
+		}
+	})
+}
+
+// Panics returns the PanicErrors recorded from the goroutines started by the GoSafe
+// method, or nil if no goroutine panics.
+func (w *WaitGroup) Panics() []error {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if len(w.panics) == 0 {
+		return nil
+	}
+	return append([]error(nil), w.panics...)
+}
+
 // MultiGo uses n goroutines to run the f function. Panic if n <= 0.
 func (w *WaitGroup) MultiGo(n int, f func()) {
 	if n <= 0 {
@@ -44,17 +124,130 @@ func (w *WaitGroup) MultiGo(n int, f func()) {
 	}
 
 	w.wg.Add(n)
+	w.launch(n)
 	for i := 0; i < n; i++ {
 		go w.do(f)
 	}
 }
 
+// MultiGoLimit runs the f function for each index in [0, n), but no more than limit
+// goroutines run the f function simultaneously. The goroutines are started by a
+// single dispatcher goroutine, which waits for a slot of the internal semaphore
+// before starting each one, so this method does not block, and at most limit
+// goroutines are alive besides the dispatcher. Panic if n <= 0 or limit <= 0.
+func (w *WaitGroup) MultiGoLimit(n, limit int, f func(i int)) {
+	if n <= 0 {
+		panic("WaitGroup.MultiGoLimit(): n must be a positive integer")
+	}
+	if limit <= 0 {
+		panic("WaitGroup.MultiGoLimit(): limit must be a positive integer")
+	}
+
+	w.wg.Add(n)
+	sem := make(chan struct{}, limit)
+	go func() {
+		for i := 0; i < n; i++ {
+			sem <- struct{}{}
+			w.launch(1)
+			go func(i int) {
+				defer func() { <-sem }()
+				w.do(func() { f(i) })
+			}(i)
+		}
+	}()
+}
+
+// Update the counters for the n goroutines to be launched.
+func (w *WaitGroup) launch(n int) {
+	atomic.AddInt64(&w.launched, int64(n))
+	running := atomic.AddInt64(&w.running, int64(n))
+	for {
+		peak := atomic.LoadInt64(&w.peak)
+		if running <= peak || atomic.CompareAndSwapInt64(&w.peak, peak, running) {
+			return
+		}
+	}
+}
+
 func (w *WaitGroup) do(f func()) {
 	defer w.wg.Done()
+	defer atomic.AddInt64(&w.running, -1)
+	if w.OnPanic != nil {
+		defer w.handlePanic()
+	}
 	f()
 }
 
+func (w *WaitGroup) handlePanic() {
+	if v := recover(); v != nil {
+		w.OnPanic(v, debug.Stack())
+	}
+}
+
 // Wait blocks waiting for all goroutines to exit.
 func (w *WaitGroup) Wait() {
 	w.wg.Wait()
 }
+
+// WaitTimeout blocks waiting for all goroutines to exit or the timeout to expire,
+// and returns true if all goroutines exited before the timeout.
+// The waiting is done by a single helper goroutine shared by all the concurrent
+// callers, which exits when all goroutines of the group exit, even if the callers
+// have given up waiting. Calling this method does not leak more helper goroutines.
+func (w *WaitGroup) WaitTimeout(d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-w.waitDone():
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// WaitContext blocks waiting for all goroutines to exit or the given context to be
+// done, and returns nil if all goroutines exited, otherwise returns the error of
+// the context. Like WaitGroup.WaitTimeout, it shares the single helper goroutine.
+// It is worth noting that the goroutines of the group are not stopped when the
+// context is done, they keep running until they exit by themselves.
+func (w *WaitGroup) WaitContext(ctx context.Context) error {
+	select {
+	case <-w.waitDone():
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Returns the channel that is closed after all goroutines exit.
+func (w *WaitGroup) waitDone() <-chan struct{} {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.done == nil {
+		done := make(chan struct{})
+		w.done = done
+		go func() {
+			w.wg.Wait()
+			w.mutex.Lock()
+			// The group can be reused after all goroutines exit, the next caller
+			// starts a new helper goroutine.
+			w.done = nil
+			w.mutex.Unlock()
+			close(done)
+		}()
+	}
+	return w.done
+}
+
+// TotalLaunched returns the total number of the goroutines launched by the group.
+func (w *WaitGroup) TotalLaunched() int64 {
+	return atomic.LoadInt64(&w.launched)
+}
+
+// PeakConcurrency returns the maximum number of the outstanding goroutines of the
+// group observed simultaneously.
+func (w *WaitGroup) PeakConcurrency() int64 {
+	return atomic.LoadInt64(&w.peak)
+}
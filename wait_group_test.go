@@ -15,8 +15,16 @@
 package runner
 
 import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestWaitGroup(t *testing.T) {
@@ -32,6 +40,55 @@ func TestWaitGroup(t *testing.T) {
 	}
 }
 
+func TestWaitGroup_GoE(t *testing.T) {
+	var wg WaitGroup
+	wg.GoE(func() error { return nil })
+	wg.Wait()
+	if err := wg.Err(); err != nil {
+		t.Fatalf("WaitGroup.Err(): %s", err)
+	}
+
+	wg.GoE(func() error { return errors.New("test") })
+	wg.Wait()
+	if err := wg.Err(); err == nil || err.Error() != "test" {
+		t.Fatalf("WaitGroup.Err(): %v", err)
+	}
+
+	wg.GoE(func() error { panic("test panic") })
+	wg.Go(func() {})
+	wg.Wait()
+	err := wg.Err()
+	es, ok := err.(*Errors)
+	if !ok || es.Len() != 2 || !IsPanicError(es.Last()) {
+		t.Fatalf("WaitGroup.Err(): %v", err)
+	}
+}
+
+func TestWaitGroup_GoSafe(t *testing.T) {
+	var wg WaitGroup
+	var n int64
+	wg.GoSafe(func() { atomic.AddInt64(&n, 1) })
+	wg.Wait()
+	if panics := wg.Panics(); panics != nil || n != 1 {
+		t.Fatalf("WaitGroup.Panics(): %v %d", panics, n)
+	}
+
+	wg.GoSafe(func() { panic("test1") })
+	wg.GoSafe(func() { panic("test2") })
+	wg.GoSafe(func() { atomic.AddInt64(&n, 1) })
+	wg.Wait()
+
+	panics := wg.Panics()
+	if len(panics) != 2 || n != 2 {
+		t.Fatalf("WaitGroup.Panics(): %v %d", panics, n)
+	}
+	for _, err := range panics {
+		if e, ok := err.(*PanicError); !ok || !strings.HasPrefix(e.Error(), "test") || e.Stack() == nil {
+			t.Fatalf("WaitGroup.Panics(): %v", err)
+		}
+	}
+}
+
 func TestWaitGroup_Panic(t *testing.T) {
 	defer func() {
 		if v := recover(); v == nil {
@@ -40,3 +97,203 @@ func TestWaitGroup_Panic(t *testing.T) {
 	}()
 	new(WaitGroup).MultiGo(0, func() {})
 }
+
+func TestWaitGroup_OnPanic(t *testing.T) {
+	var n int64
+	var stack []byte
+	mutex := new(sync.Mutex)
+
+	wg := WaitGroup{OnPanic: func(v interface{}, s []byte) {
+		mutex.Lock()
+		defer mutex.Unlock()
+		if v == "test" {
+			n++
+			stack = s
+		}
+	}}
+	wg.Go(func() { panic("test") })
+	wg.MultiGo(2, func() { panic("test") })
+	wg.Go(func() {})
+	wg.Wait()
+
+	if n != 3 {
+		t.Fatalf("WaitGroup.OnPanic: %d", n)
+	}
+	if !strings.Contains(string(stack), "TestWaitGroup_OnPanic") {
+		t.Fatalf("WaitGroup.OnPanic: %s", stack)
+	}
+}
+
+func TestWaitGroup_PanicWithoutOnPanic(t *testing.T) {
+	if os.Getenv("ZKITS_RUNNER_WAIT_GROUP_PANIC") == "1" {
+		var wg WaitGroup
+		wg.Go(func() { panic("test") })
+		wg.Wait()
+		return
+	}
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestWaitGroup_PanicWithoutOnPanic$")
+	cmd.Env = append(os.Environ(), "ZKITS_RUNNER_WAIT_GROUP_PANIC=1")
+	if err := cmd.Run(); err == nil {
+		t.Fatal("WaitGroup: no crash")
+	}
+}
+
+func TestWaitGroup_MultiGoLimit(t *testing.T) {
+	var wg WaitGroup
+	var running, peak int64
+	seen := make([]int32, 20)
+
+	wg.MultiGoLimit(len(seen), 3, func(i int) {
+		n := atomic.AddInt64(&running, 1)
+		for {
+			p := atomic.LoadInt64(&peak)
+			if n <= p || atomic.CompareAndSwapInt64(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt32(&seen[i], 1)
+		atomic.AddInt64(&running, -1)
+	})
+	wg.Wait()
+
+	if peak < 1 || peak > 3 {
+		t.Fatalf("WaitGroup.MultiGoLimit(): peak %d", peak)
+	}
+	if n := wg.PeakConcurrency(); n > 3 {
+		t.Fatalf("WaitGroup.PeakConcurrency(): %d", n)
+	}
+	for i, n := range seen {
+		if n != 1 {
+			t.Fatalf("WaitGroup.MultiGoLimit(): [%d] %d", i, n)
+		}
+	}
+
+	// No more than limit goroutines are alive besides the dispatcher.
+	base := runtime.NumGoroutine()
+	release := make(chan struct{})
+	wg.MultiGoLimit(100, 3, func(int) { <-release })
+	time.Sleep(time.Millisecond * 20)
+	if n := runtime.NumGoroutine() - base; n > 4 {
+		t.Fatalf("WaitGroup.MultiGoLimit(): %d goroutines", n)
+	}
+	close(release)
+	wg.Wait()
+
+	for _, args := range [][2]int{{0, 1}, {1, 0}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Fatalf("WaitGroup.MultiGoLimit(): %v no panic", args)
+				}
+			}()
+			wg.MultiGoLimit(args[0], args[1], func(int) {})
+		}()
+	}
+}
+
+func TestWaitGroup_Metrics(t *testing.T) {
+	var wg WaitGroup
+	if n := wg.TotalLaunched(); n != 0 {
+		t.Fatalf("WaitGroup.TotalLaunched(): %d", n)
+	}
+	if n := wg.PeakConcurrency(); n != 0 {
+		t.Fatalf("WaitGroup.PeakConcurrency(): %d", n)
+	}
+
+	release := make(chan struct{})
+	wg.MultiGo(3, func() { <-release })
+	wg.Go(func() { <-release })
+	close(release)
+	wg.Wait()
+
+	wg.Go(func() {})
+	wg.Wait()
+
+	if n := wg.TotalLaunched(); n != 5 {
+		t.Fatalf("WaitGroup.TotalLaunched(): %d", n)
+	}
+	if n := wg.PeakConcurrency(); n != 4 {
+		t.Fatalf("WaitGroup.PeakConcurrency(): %d", n)
+	}
+}
+
+func TestWaitGroup_MetricsRace(t *testing.T) {
+	var wg WaitGroup
+	var outer sync.WaitGroup
+
+	outer.Add(10)
+	for i := 0; i < 10; i++ {
+		go func() {
+			defer outer.Done()
+			for j := 0; j < 100; j++ {
+				wg.Go(func() {})
+				_ = wg.PeakConcurrency()
+			}
+		}()
+	}
+	outer.Wait()
+	wg.Wait()
+
+	if n := wg.TotalLaunched(); n != 1000 {
+		t.Fatalf("WaitGroup.TotalLaunched(): %d", n)
+	}
+	if n := wg.PeakConcurrency(); n < 1 || n > 1000 {
+		t.Fatalf("WaitGroup.PeakConcurrency(): %d", n)
+	}
+}
+
+func TestWaitGroup_WaitTimeout(t *testing.T) {
+	var wg WaitGroup
+	if !wg.WaitTimeout(time.Millisecond * 10) {
+		t.Fatal("WaitGroup.WaitTimeout(): timeout")
+	}
+
+	release := make(chan struct{})
+	wg.Go(func() { <-release })
+	if wg.WaitTimeout(time.Millisecond * 10) {
+		t.Fatal("WaitGroup.WaitTimeout(): not timeout")
+	}
+	if wg.WaitTimeout(time.Millisecond * 10) {
+		t.Fatal("WaitGroup.WaitTimeout(): not timeout")
+	}
+	close(release)
+	if !wg.WaitTimeout(time.Second) {
+		t.Fatal("WaitGroup.WaitTimeout(): timeout")
+	}
+
+	// The group is reused after all goroutines exit.
+	release = make(chan struct{})
+	wg.Go(func() { <-release })
+	if wg.WaitTimeout(time.Millisecond * 10) {
+		t.Fatal("WaitGroup.WaitTimeout(): not timeout")
+	}
+	close(release)
+	if !wg.WaitTimeout(time.Second) {
+		t.Fatal("WaitGroup.WaitTimeout(): timeout")
+	}
+}
+
+func TestWaitGroup_WaitContext(t *testing.T) {
+	var wg WaitGroup
+	if err := wg.WaitContext(context.Background()); err != nil {
+		t.Fatalf("WaitGroup.WaitContext(): %s", err)
+	}
+
+	release := make(chan struct{})
+	wg.Go(func() { <-release })
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := wg.WaitContext(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("WaitGroup.WaitContext(): %v", err)
+	}
+	// The helper goroutine started by the context waiting is shared.
+	if wg.WaitTimeout(time.Millisecond * 10) {
+		t.Fatal("WaitGroup.WaitTimeout(): not timeout")
+	}
+	close(release)
+	if err := wg.WaitContext(context.Background()); err != nil {
+		t.Fatalf("WaitGroup.WaitContext(): %s", err)
+	}
+}
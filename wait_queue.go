@@ -15,6 +15,7 @@
 package runner
 
 import (
+	"context"
 	"sync"
 )
 
@@ -26,9 +27,17 @@ type WaitQueue interface {
 	// NewWaiter creates a receiptable waiter and adds it to the wait queue.
 	NewReceiptableWaiter() ReceiptableWaiter
 
+	// NewTaggedWaiter creates a waiter with the given tag and adds it to the wait queue.
+	// The tag is used by the ReleaseWhere method to select the waiters to release.
+	NewTaggedWaiter(interface{}) Waiter
+
 	// Len returns the number of waiters in the current queue.
 	Len() int
 
+	// Peek returns the pure waiter at the front of the queue without releasing it,
+	// and reports whether the queue is not empty.
+	Peek() (Waiter, bool)
+
 	// Release releases up to the top n waiters in the queue.
 	// This method returns the number of released waiters, the range is [0, n].
 	// The release sequence is the same as the enqueue sequence.
@@ -38,6 +47,37 @@ type WaitQueue interface {
 	// This method returns the number of released waiters.
 	// The release sequence is the same as the enqueue sequence.
 	ReleaseAll() int
+
+	// ReleaseLast releases up to the last n waiters in the queue.
+	// This method returns the number of released waiters, the range is [0, n].
+	// The release sequence is the reverse of the enqueue sequence.
+	ReleaseLast(int) int
+
+	// ReleaseAllOnContext releases all the waiters in the queue when the given context
+	// is done, including the waiters added after this method is called. This method
+	// returns immediately, the release is performed by a watcher coroutine, which
+	// keeps running until the given context is done.
+	ReleaseAllOnContext(context.Context)
+
+	// ReleaseIf releases up to the top n live waiters in the queue.
+	// A receiptable waiter whose Done method has been called before the release is
+	// dead, which means that the consumer has given up waiting. The given function
+	// can also report the waiter is dead by returning false, it can be nil.
+	// The dead waiters are removed from the queue without taking the release slots.
+	// This method returns the number of released live waiters, the range is [0, n].
+	ReleaseIf(int, func(Waiter) bool) int
+
+	// ReleaseWaiter releases the given waiter previously returned by the current
+	// queue, and removes it from the queue regardless of its position.
+	// This method returns false if the given waiter is not in the queue.
+	ReleaseWaiter(Waiter) bool
+
+	// ReleaseWhere releases the waiters whose tags satisfy the given function, and
+	// removes them from the queue, the order of the remaining waiters is preserved.
+	// The waiters created without a tag have the nil tag.
+	// This method returns the number of released waiters.
+	// The release sequence is the same as the enqueue sequence.
+	ReleaseWhere(func(interface{}) bool) int
 }
 
 // NewWaitQueue creates and returns a new WaitQueue instance.
@@ -48,7 +88,42 @@ func NewWaitQueue() WaitQueue {
 // The built-in WaitQueue.
 type waitQueue struct {
 	mutex sync.Mutex
-	queue []Closeable
+	queue []*waitQueueItem
+}
+
+// The waitQueueItem type is the item of the wait queue.
+type waitQueueItem struct {
+	waiter Waiter
+	// The built-in waiters are idempotent, so they are closed directly.
+	closer Closeable
+	// The done channel of the receiptable waiter, nil for the pure waiter.
+	done <-chan struct{}
+	tag  interface{}
+}
+
+// Close closes the waiter of the current item, and waits for the consumer to
+// complete if the waiter is receiptable.
+func (item *waitQueueItem) Close() {
+	item.closer.Close()
+	if item.done != nil {
+		<-item.done
+	}
+}
+
+// Discard closes the waiter of the current item without waiting for the consumer.
+func (item *waitQueueItem) discard() { item.closer.Close() }
+
+// Determines whether the consumer of the current item is still waiting.
+func (item *waitQueueItem) alive() bool {
+	if item.done == nil {
+		return true
+	}
+	select {
+	case <-item.done:
+		return false
+	default:
+		return true
+	}
 }
 
 // NewWaiter creates a waiter and adds it to the wait queue.
@@ -57,7 +132,17 @@ func (wq *waitQueue) NewWaiter() Waiter {
 	defer wq.mutex.Unlock()
 
 	w := NewCloseableWaiter()
-	wq.queue = append(wq.queue, w)
+	wq.queue = append(wq.queue, &waitQueueItem{waiter: w.Waiter(), closer: w})
+	return w.Waiter()
+}
+
+// NewTaggedWaiter creates a waiter with the given tag and adds it to the wait queue.
+func (wq *waitQueue) NewTaggedWaiter(tag interface{}) Waiter {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	w := NewCloseableWaiter()
+	wq.queue = append(wq.queue, &waitQueueItem{waiter: w.Waiter(), closer: w, tag: tag})
 	return w.Waiter()
 }
 
@@ -67,7 +152,11 @@ func (wq *waitQueue) NewReceiptableWaiter() ReceiptableWaiter {
 	defer wq.mutex.Unlock()
 
 	w := NewDuplexWaiter()
-	wq.queue = append(wq.queue, CloseableFunc(w.CloseAndWaitDone))
+	wq.queue = append(wq.queue, &waitQueueItem{
+		waiter: w.Waiter(),
+		closer: w,
+		done:   w.DoneChannel(),
+	})
 	return w.Waiter()
 }
 
@@ -79,6 +168,18 @@ func (wq *waitQueue) Len() (n int) {
 	return
 }
 
+// Peek returns the pure waiter at the front of the queue without releasing it,
+// and reports whether the queue is not empty.
+func (wq *waitQueue) Peek() (Waiter, bool) {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	if len(wq.queue) == 0 {
+		return nil, false
+	}
+	return wq.queue[0].waiter, true
+}
+
 // Release releases up to the top n waiters in the queue.
 // This method returns the number of released waiters, the range is [0, n].
 // The release sequence is the same as the enqueue sequence.
@@ -93,7 +194,7 @@ func (wq *waitQueue) Release(n int) int {
 		if n >= m {
 			wq.queue = nil
 		} else {
-			queue := make([]Closeable, m-n)
+			queue := make([]*waitQueueItem, m-n)
 			copy(queue, wq.queue[n:])
 			wq.queue = queue
 		}
@@ -102,6 +203,29 @@ func (wq *waitQueue) Release(n int) int {
 	return 0
 }
 
+// ReleaseLast releases up to the last n waiters in the queue.
+// This method returns the number of released waiters, the range is [0, n].
+// The release sequence is the reverse of the enqueue sequence.
+func (wq *waitQueue) ReleaseLast(n int) int {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	if m := len(wq.queue); m > 0 && n > 0 {
+		for i := m - 1; i >= 0 && i >= m-n; i-- {
+			wq.queue[i].Close()
+		}
+		if n >= m {
+			wq.queue = nil
+		} else {
+			queue := make([]*waitQueueItem, m-n)
+			copy(queue, wq.queue[:m-n])
+			wq.queue = queue
+		}
+		return m - len(wq.queue)
+	}
+	return 0
+}
+
 // ReleaseAll releases all the waiters in the queue. This method returns
 // the number of released waiters. The release sequence is the same as
 // the enqueue sequence.
@@ -117,3 +241,93 @@ func (wq *waitQueue) ReleaseAll() (n int) {
 	}
 	return
 }
+
+// ReleaseAllOnContext releases all the waiters in the queue when the given context
+// is done, including the waiters added after this method is called.
+func (wq *waitQueue) ReleaseAllOnContext(ctx context.Context) {
+	// The context that is never done, such as the context.Background().
+	if ctx.Done() == nil {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		wq.ReleaseAll()
+	}()
+}
+
+// ReleaseIf releases up to the top n live waiters in the queue.
+// A receiptable waiter whose Done method has been called before the release is
+// dead, which means that the consumer has given up waiting. The given function
+// can also report the waiter is dead by returning false, it can be nil.
+// The dead waiters are removed from the queue without taking the release slots.
+// This method returns the number of released live waiters, the range is [0, n].
+func (wq *waitQueue) ReleaseIf(n int, ready func(Waiter) bool) (r int) {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	var i int
+	for m := len(wq.queue); i < m && r < n; i++ {
+		if item := wq.queue[i]; item.alive() && (ready == nil || ready(item.waiter)) {
+			item.Close()
+			r++
+		} else {
+			// Closing the dead waiter is harmless, no one is waiting for it.
+			item.discard()
+		}
+	}
+	if i > 0 {
+		queue := make([]*waitQueueItem, len(wq.queue)-i)
+		copy(queue, wq.queue[i:])
+		wq.queue = queue
+	}
+	return
+}
+
+// ReleaseWaiter releases the given waiter previously returned by the current
+// queue, and removes it from the queue regardless of its position.
+// This method returns false if the given waiter is not in the queue.
+func (wq *waitQueue) ReleaseWaiter(w Waiter) bool {
+	if w == nil {
+		return false
+	}
+	c := w.Channel()
+
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	for i, item := range wq.queue {
+		// Compare the channels, the given waiter may be of a non-comparable type.
+		if item.waiter.Channel() == c {
+			item.Close()
+			queue := make([]*waitQueueItem, 0, len(wq.queue)-1)
+			queue = append(queue, wq.queue[:i]...)
+			wq.queue = append(queue, wq.queue[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ReleaseWhere releases the waiters whose tags satisfy the given function, and
+// removes them from the queue, the order of the remaining waiters is preserved.
+// The waiters created without a tag have the nil tag.
+// This method returns the number of released waiters.
+// The release sequence is the same as the enqueue sequence.
+func (wq *waitQueue) ReleaseWhere(match func(interface{}) bool) (n int) {
+	wq.mutex.Lock()
+	defer wq.mutex.Unlock()
+
+	var queue []*waitQueueItem
+	for _, item := range wq.queue {
+		if match(item.tag) {
+			item.Close()
+			n++
+		} else {
+			queue = append(queue, item)
+		}
+	}
+	if n > 0 {
+		wq.queue = queue
+	}
+	return
+}
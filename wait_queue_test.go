@@ -15,9 +15,11 @@
 package runner
 
 import (
+	"context"
 	"strings"
 	"sync"
 	"testing"
+	"time"
 )
 
 func TestWaitQueue(t *testing.T) {
@@ -153,3 +155,232 @@ func TestWaitQueue(t *testing.T) {
 		}
 	}
 }
+
+func TestWaitQueue_ReleaseIf(t *testing.T) {
+	wq := NewWaitQueue()
+
+	// The consumer of the first waiter has given up.
+	w1 := wq.NewReceiptableWaiter()
+	w1.Done()
+
+	w2 := wq.NewReceiptableWaiter()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w2.Wait()
+		w2.Done()
+	}()
+
+	w3 := wq.NewWaiter()
+	w4 := wq.NewWaiter()
+	w5 := wq.NewWaiter()
+
+	// The w3 is reported as dead by the given function.
+	if n := wq.ReleaseIf(2, func(w Waiter) bool { return w != w3 }); n != 2 {
+		t.Fatalf("WaitQueue.ReleaseIf(): %d", n)
+	}
+	<-done
+	for _, w := range []Waiter{w1, w3, w4} {
+		select {
+		case <-w.Channel():
+		default:
+			t.Fatal("WaitQueue.ReleaseIf(): not closed")
+		}
+	}
+	if n := wq.Len(); n != 1 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+
+	if n := wq.ReleaseIf(2, nil); n != 1 {
+		t.Fatalf("WaitQueue.ReleaseIf(): %d", n)
+	}
+	w5.Wait()
+	if n := wq.ReleaseIf(1, nil); n != 0 {
+		t.Fatalf("WaitQueue.ReleaseIf(): %d", n)
+	}
+}
+
+func TestWaitQueue_ReleaseWaiter(t *testing.T) {
+	wq := NewWaitQueue()
+	w1 := wq.NewWaiter()
+	w2 := wq.NewReceiptableWaiter()
+	w3 := wq.NewWaiter()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		w2.Wait()
+		w2.Done()
+	}()
+
+	if !wq.ReleaseWaiter(w2) {
+		t.Fatal("WaitQueue.ReleaseWaiter(): false")
+	}
+	<-done
+	if wq.ReleaseWaiter(w2) {
+		t.Fatal("WaitQueue.ReleaseWaiter(): released twice")
+	}
+	if wq.ReleaseWaiter(NewCloseableWaiter().Waiter()) || wq.ReleaseWaiter(nil) {
+		t.Fatal("WaitQueue.ReleaseWaiter(): unknown waiter released")
+	}
+	if n := wq.Len(); n != 2 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+
+	if n := wq.Release(1); n != 1 {
+		t.Fatalf("WaitQueue.Release(): %d", n)
+	}
+	w1.Wait()
+	select {
+	case <-w3.Channel():
+		t.Fatal("WaitQueue.Release(): w3 closed")
+	default:
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() { defer wg.Done(); wq.ReleaseWaiter(w3) }()
+	go func() { defer wg.Done(); wq.ReleaseAll() }()
+	wg.Wait()
+	w3.Wait()
+	if n := wq.Len(); n != 0 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+}
+
+func TestWaitQueue_ReleaseAllOnContext(t *testing.T) {
+	wq := NewWaitQueue()
+	w1 := wq.NewWaiter()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	wq.ReleaseAllOnContext(ctx)
+	wq.ReleaseAllOnContext(context.Background())
+	w2 := wq.NewReceiptableWaiter()
+	go func() {
+		w2.Wait()
+		w2.Done()
+	}()
+
+	select {
+	case <-w1.Channel():
+		t.Fatal("WaitQueue.ReleaseAllOnContext(): w1 closed")
+	default:
+	}
+
+	cancel()
+	w1.Wait()
+	w2.Wait()
+	for i := 0; i < 100 && wq.Len() != 0; i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if n := wq.Len(); n != 0 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+}
+
+func TestWaitQueue_ReleaseWhere(t *testing.T) {
+	wq := NewWaitQueue()
+	w1 := wq.NewTaggedWaiter("a")
+	w2 := wq.NewTaggedWaiter("b")
+	w3 := wq.NewWaiter()
+	w4 := wq.NewTaggedWaiter("a")
+
+	if n := wq.ReleaseWhere(func(tag interface{}) bool { return tag == "a" }); n != 2 {
+		t.Fatalf("WaitQueue.ReleaseWhere(): %d", n)
+	}
+	w1.Wait()
+	w4.Wait()
+	if n := wq.Len(); n != 2 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+	if n := wq.ReleaseWhere(func(tag interface{}) bool { return tag == "c" }); n != 0 {
+		t.Fatalf("WaitQueue.ReleaseWhere(): %d", n)
+	}
+
+	// The order of the remaining waiters is preserved.
+	if n := wq.Release(1); n != 1 {
+		t.Fatalf("WaitQueue.Release(): %d", n)
+	}
+	w2.Wait()
+	select {
+	case <-w3.Channel():
+		t.Fatal("WaitQueue.Release(): w3 closed")
+	default:
+	}
+	if n := wq.ReleaseWhere(func(tag interface{}) bool { return tag == nil }); n != 1 {
+		t.Fatalf("WaitQueue.ReleaseWhere(): %d", n)
+	}
+	w3.Wait()
+	if n := wq.Len(); n != 0 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+}
+
+func TestWaitQueue_ReleaseLast(t *testing.T) {
+	wq := NewWaitQueue()
+	if n := wq.ReleaseLast(1); n != 0 {
+		t.Fatalf("WaitQueue.ReleaseLast(): %d", n)
+	}
+
+	var ss []string
+	mutex := new(sync.Mutex)
+	wg := new(sync.WaitGroup)
+	for _, s := range []string{"A", "B", "C"} {
+		wg.Add(1)
+		go func(s string, w ReceiptableWaiter) {
+			defer wg.Done()
+			defer w.Done()
+			w.Wait()
+			mutex.Lock()
+			ss = append(ss, s)
+			mutex.Unlock()
+		}(s, wq.NewReceiptableWaiter())
+	}
+
+	if n := wq.ReleaseLast(0); n != 0 {
+		t.Fatalf("WaitQueue.ReleaseLast(): %d", n)
+	}
+	if n := wq.ReleaseLast(2); n != 2 {
+		t.Fatalf("WaitQueue.ReleaseLast(): %d", n)
+	}
+	if n := wq.Len(); n != 1 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+	if n := wq.ReleaseLast(5); n != 1 {
+		t.Fatalf("WaitQueue.ReleaseLast(): %d", n)
+	}
+	wg.Wait()
+	if got := strings.Join(ss, "-"); got != "C-B-A" {
+		t.Fatalf("WaitQueue.ReleaseLast(): %s", got)
+	}
+	if n := wq.Len(); n != 0 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+}
+
+func TestWaitQueue_Peek(t *testing.T) {
+	wq := NewWaitQueue()
+	if w, ok := wq.Peek(); ok || w != nil {
+		t.Fatalf("WaitQueue.Peek(): %v %v", w, ok)
+	}
+
+	w1 := wq.NewWaiter()
+	wq.NewReceiptableWaiter()
+	w, ok := wq.Peek()
+	if !ok || w.Channel() != w1.Channel() {
+		t.Fatalf("WaitQueue.Peek(): %v %v", w, ok)
+	}
+	select {
+	case <-w.Channel():
+		t.Fatal("WaitQueue.Peek(): w1 closed")
+	default:
+	}
+	if n := wq.Len(); n != 2 {
+		t.Fatalf("WaitQueue.Len(): %d", n)
+	}
+
+	wq.Release(1)
+	if w, ok := wq.Peek(); !ok || w.Channel() == w1.Channel() {
+		t.Fatalf("WaitQueue.Peek(): %v %v", w, ok)
+	}
+}
@@ -0,0 +1,84 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync/atomic"
+)
+
+// These are the states of the wait task.
+const (
+	waitTaskRunning int32 = iota
+	waitTaskStopping
+	waitTaskChildExited
+)
+
+// NewWaitTask creates a task that links the lifecycle of the given child runner
+// with the parent runner running the task.
+// The Execute method starts a coroutine that calls the Wait method of the child runner.
+// When the child runner exits by itself (for example, by the exit signal), the given
+// exit function is called, it usually calls the Exit method of the parent runner.
+// The Shutdown method exits the child runner. If the task is shut down by the parent
+// runner first, the exit function will not be called, and if the child runner exits
+// by itself first, the Shutdown method will not wait for the coroutine, so calling
+// the Exit method of the parent runner in the exit function never deadlocks.
+func NewWaitTask(child Runner, exit func()) Task {
+	return &waitTask{child: child, exit: exit, waiter: NewCloseableWaiter()}
+}
+
+// The waitTask type is used to link the lifecycle of the child runner.
+type waitTask struct {
+	child  Runner
+	exit   func()
+	state  int32
+	waiter CloseableWaiter
+	err    error
+}
+
+// Execute method starts a coroutine to wait for the child runner.
+func (t *waitTask) Execute() error {
+	goManaged(t.wait, t.exited)
+	return nil
+}
+
+// Wait for the child runner to exit.
+func (t *waitTask) wait() {
+	t.err = t.child.Wait()
+}
+
+// Called after the child runner exits. If the child runner exits by itself,
+// call the exit function.
+func (t *waitTask) exited() {
+	t.waiter.Close()
+
+	if atomic.CompareAndSwapInt32(&t.state, waitTaskRunning, waitTaskChildExited) && t.exit != nil {
+		t.exit()
+	}
+}
+
+// Shutdown method exits the child runner.
+func (t *waitTask) Shutdown() error {
+	if !atomic.CompareAndSwapInt32(&t.state, waitTaskRunning, waitTaskStopping) {
+		// The child runner has exited by itself, and the coroutine may be calling
+		// the exit function, so we must not wait for it.
+		return t.err
+	}
+
+	errs := new(Errors)
+	errs.Add(t.child.Exit())
+	t.waiter.Wait()
+	errs.Add(t.err)
+	return errs.result()
+}
@@ -0,0 +1,60 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestNewWaitTask(t *testing.T) {
+	parent := New()
+	child := New()
+
+	var n int
+	child.MustRun(NewTaskFromFunc(nil, func() error {
+		n++
+		return errors.New("test")
+	}))
+	parent.MustRun(NewWaitTask(child, func() { t.Error("NewWaitTask(): exit called") }))
+
+	if err := parent.Exit(); err == nil || err.Error() != "test" {
+		t.Fatalf("Runner.Exit(): %v", err)
+	}
+	if !child.Exited() {
+		t.Fatal("Runner.Exited(): false")
+	}
+	if n != 1 {
+		t.Fatalf("NewWaitTask(): %d", n)
+	}
+}
+
+func TestNewWaitTask_ChildExit(t *testing.T) {
+	parent := New()
+	child := New()
+
+	done := make(chan error, 1)
+	parent.MustRun(NewWaitTask(child, func() { done <- parent.Exit() }))
+
+	if err := child.Exit(); err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Runner.Exit(): %s", err)
+	}
+	if !parent.Exited() {
+		t.Fatal("Runner.Exited(): false")
+	}
+}
@@ -15,7 +15,9 @@
 package runner
 
 import (
+	"context"
 	"sync"
+	"time"
 )
 
 // Waiter interface defines the waiter.
@@ -29,6 +31,91 @@ type Waiter interface {
 	Channel() <-chan struct{}
 }
 
+// TimeoutWaiter interface defines the waiter that can wait with a time limit.
+// The built-in waiters created by the NewCloseableWaiter and NewDuplexWaiter
+// functions implement it.
+type TimeoutWaiter interface {
+	Waiter
+
+	// WaitTimeout blocks the current coroutine and waits for the current waiter to be
+	// closed within the given duration. It returns true if the waiter is closed, or
+	// false if the duration elapses first.
+	WaitTimeout(time.Duration) bool
+}
+
+// WaitTimeout waits for the given waiter to be closed within the given duration.
+// It returns true if the waiter is closed, or false if the duration elapses first.
+// If the given waiter implements the TimeoutWaiter interface, its WaitTimeout method
+// is called directly.
+func WaitTimeout(w Waiter, d time.Duration) bool {
+	if tw, ok := w.(TimeoutWaiter); ok {
+		return tw.WaitTimeout(d)
+	}
+	return waitTimeout(w.Channel(), d)
+}
+
+// Waits for the given channel to be closed within the given duration.
+// The timer is always stopped, so it is released immediately when the channel is
+// closed first, instead of when the duration elapses.
+func waitTimeout(c <-chan struct{}, d time.Duration) bool {
+	select {
+	case <-c:
+		return true
+	default:
+	}
+	if d <= 0 {
+		return false
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-c:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
+
+// ContextWaiter interface defines the waiter that can wait with a context.
+// The built-in waiters created by the NewCloseableWaiter and NewDuplexWaiter
+// functions implement it.
+type ContextWaiter interface {
+	Waiter
+
+	// WaitContext blocks the current coroutine and waits for the current waiter to be
+	// closed or the given context to be done. It returns nil if the waiter is closed,
+	// even if the context is also done, otherwise it returns the context error.
+	WaitContext(context.Context) error
+}
+
+// WaitContext waits for the given waiter to be closed or the given context to be done.
+// It returns nil if the waiter is closed, even if the context is also done, otherwise
+// it returns the context error.
+func WaitContext(ctx context.Context, w Waiter) error {
+	if cw, ok := w.(ContextWaiter); ok {
+		return cw.WaitContext(ctx)
+	}
+	return waitContext(ctx, w.Channel())
+}
+
+// Waits for the given channel to be closed or the given context to be done.
+func waitContext(ctx context.Context, c <-chan struct{}) error {
+	// The closed channel takes precedence over the done context.
+	select {
+	case <-c:
+		return nil
+	default:
+	}
+
+	select {
+	case <-c:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // ReceiptableWaiter interface defines the receiptable waiter.
 type ReceiptableWaiter interface {
 	Waiter
@@ -73,6 +160,19 @@ type DuplexWaiter interface {
 	CloseAndWaitDone()
 }
 
+// ResettableDuplexWaiter interface defines the duplex waiter that can be reused
+// for multiple cycles.
+type ResettableDuplexWaiter interface {
+	DuplexWaiter
+
+	// Reset starts a new cycle of the current waiter, so it can be reused.
+	// The channels of the current cycle are closed first, so all the coroutines blocked
+	// on the Wait, WaitDone and CloseAndWaitDone methods of the previous cycle are released
+	// before the reset takes effect. The channels obtained by the Channel and DoneChannel
+	// methods before the reset belong to the previous cycle.
+	Reset()
+}
+
 // The built-in Waiter.
 type channelWaiter struct {
 	c chan struct{}
@@ -94,6 +194,20 @@ func (w *channelWaiter) Channel() <-chan struct{} {
 	return w.c
 }
 
+// WaitTimeout blocks the current coroutine and waits for the current waiter to be
+// closed within the given duration. It returns true if the waiter is closed, or
+// false if the duration elapses first.
+func (w *channelWaiter) WaitTimeout(d time.Duration) bool {
+	return waitTimeout(w.c, d)
+}
+
+// WaitContext blocks the current coroutine and waits for the current waiter to be
+// closed or the given context to be done. It returns nil if the waiter is closed,
+// even if the context is also done, otherwise it returns the context error.
+func (w *channelWaiter) WaitContext(ctx context.Context) error {
+	return waitContext(ctx, w.c)
+}
+
 // NewCloseableWaiter creates and returns a new CloseableWaiter instance.
 func NewCloseableWaiter() CloseableWaiter {
 	return newCloseableWaiter()
@@ -119,31 +233,83 @@ func (w *closeableWaiter) Close() { w.once.Do(w.close) }
 func (w *closeableWaiter) close() { close(w.c) }
 
 // The built-in ReceiptableWaiter.
+// The channels are guarded by the mutex, because they are replaced by the Reset
+// method of the DuplexWaiter.
 type receiptableWaiter struct {
-	*channelWaiter
-	d    chan struct{}
-	once sync.Once
+	mutex  sync.RWMutex
+	c, d   chan struct{}
+	closed bool
+	done   bool
 }
 
 // Create and return a new built-in ReceiptableWaiter instance.
 func newReceiptableWaiter() *receiptableWaiter {
-	return &receiptableWaiter{channelWaiter: newChannelWaiter(), d: make(chan struct{})}
+	return &receiptableWaiter{c: make(chan struct{}), d: make(chan struct{})}
+}
+
+// Wait blocks the current coroutine and waits for the current waiter to be closed.
+// For waiters that have been closed, this method will not block.
+// Essentially, this method is relative to: <-Channel().
+func (w *receiptableWaiter) Wait() { <-w.Channel() }
+
+// Channel returns a read-only channel that can be used for select.
+// For waiters that have been closed, this method returns a closed channel.
+func (w *receiptableWaiter) Channel() <-chan struct{} {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
+	return w.c
+}
+
+// WaitContext blocks the current coroutine and waits for the current waiter to be
+// closed or the given context to be done. It returns nil if the waiter is closed,
+// even if the context is also done, otherwise it returns the context error.
+func (w *receiptableWaiter) WaitContext(ctx context.Context) error {
+	return waitContext(ctx, w.Channel())
+}
+
+// WaitTimeout blocks the current coroutine and waits for the current waiter to be
+// closed within the given duration. It returns true if the waiter is closed, or
+// false if the duration elapses first.
+func (w *receiptableWaiter) WaitTimeout(d time.Duration) bool {
+	return waitTimeout(w.Channel(), d)
 }
 
 // Done reports that the current waiter has completed and is about to exit.
-func (w *receiptableWaiter) Done() { w.once.Do(w.done) }
+func (w *receiptableWaiter) Done() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.doneLocked()
+}
+
+// Close the done channel of the current cycle, the mutex must be held.
+func (w *receiptableWaiter) doneLocked() {
+	if !w.done {
+		w.done = true
+		close(w.d)
+	}
+}
 
-func (w *receiptableWaiter) done() { close(w.d) }
+// Close the wait channel of the current cycle, the mutex must be held.
+func (w *receiptableWaiter) closeLocked() {
+	if !w.closed {
+		w.closed = true
+		close(w.c)
+	}
+}
 
 // NewDuplexWaiter creates and returns a new DuplexWaiter instance.
 func NewDuplexWaiter() DuplexWaiter {
 	return newDuplexWaiter()
 }
 
-// The built-in DuplexWaiter.
+// NewResettableDuplexWaiter creates and returns a new ResettableDuplexWaiter instance.
+func NewResettableDuplexWaiter() ResettableDuplexWaiter {
+	return newDuplexWaiter()
+}
+
+// The built-in DuplexWaiter and ResettableDuplexWaiter.
 type duplexWaiter struct {
 	*receiptableWaiter
-	once sync.Once
 }
 
 // Create and return a new built-in DuplexWaiter instance.
@@ -159,9 +325,11 @@ func (w *duplexWaiter) Waiter() ReceiptableWaiter {
 }
 
 // Close closes the current waiter. This method is idempotent.
-func (w *duplexWaiter) Close() { w.once.Do(w.close) }
-
-func (w *duplexWaiter) close() { close(w.c) }
+func (w *duplexWaiter) Close() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.closeLocked()
+}
 
 // WaitDone waits for the Done() method of the current waiter to be called.
 // This method returns only after the Done() method is called by the coroutine holding
@@ -172,12 +340,32 @@ func (w *duplexWaiter) WaitDone() { <-w.DoneChannel() }
 // DoneChannel returns a read-only channel. When the Done() method of the current waiter
 // is called, this channel will be closed.
 func (w *duplexWaiter) DoneChannel() <-chan struct{} {
+	w.mutex.RLock()
+	defer w.mutex.RUnlock()
 	return w.d
 }
 
 // CloseAndWaitDone closes the current waiter and waits for the Done() method of the
 // current waiter to be called.
 func (w *duplexWaiter) CloseAndWaitDone() {
+	// The channel is obtained before closing, so a concurrent Reset does not
+	// make this method wait for the next cycle.
+	d := w.DoneChannel()
 	w.Close()
-	w.WaitDone()
+	<-d
+}
+
+// Reset starts a new cycle of the current waiter, so it can be reused.
+// The channels of the current cycle are closed first, so all the coroutines blocked
+// on the Wait, WaitDone and CloseAndWaitDone methods of the previous cycle are released
+// before the reset takes effect. The channels obtained by the Channel and DoneChannel
+// methods before the reset belong to the previous cycle.
+func (w *duplexWaiter) Reset() {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	w.closeLocked()
+	w.doneLocked()
+	w.c, w.d = make(chan struct{}), make(chan struct{})
+	w.closed, w.done = false, false
 }
@@ -0,0 +1,86 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync"
+)
+
+// WaiterMap interface defines the map of the keyed waiters, which is used to
+// coalesce the concurrent operations with the same key, such as fetching the same
+// resource concurrently.
+type WaiterMap interface {
+	// Wait returns the waiter of the given key. If the key is in flight, the existing
+	// waiter and false are returned, the caller waits for it. Otherwise, a new waiter
+	// and true are returned, the caller performs the operation and must call the
+	// Done method with the given key when it is done.
+	Wait(string) (Waiter, bool)
+
+	// Done releases the waiter of the given key and removes the key from the map.
+	// This method returns false if the given key is not in flight.
+	Done(string) bool
+
+	// Len returns the number of the keys in flight.
+	Len() int
+}
+
+// NewWaiterMap creates and returns a new WaiterMap instance.
+func NewWaiterMap() WaiterMap {
+	return &waiterMap{waiters: make(map[string]CloseableWaiter)}
+}
+
+// The built-in WaiterMap.
+type waiterMap struct {
+	mutex   sync.Mutex
+	waiters map[string]CloseableWaiter
+}
+
+// Wait returns the waiter of the given key. If the key is in flight, the existing
+// waiter and false are returned, the caller waits for it. Otherwise, a new waiter
+// and true are returned, the caller performs the operation and must call the
+// Done method with the given key when it is done.
+func (m *waiterMap) Wait(key string) (Waiter, bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if w, found := m.waiters[key]; found {
+		return w.Waiter(), false
+	}
+	w := NewCloseableWaiter()
+	m.waiters[key] = w
+	return w.Waiter(), true
+}
+
+// Done releases the waiter of the given key and removes the key from the map.
+// This method returns false if the given key is not in flight.
+func (m *waiterMap) Done(key string) bool {
+	m.mutex.Lock()
+	w, found := m.waiters[key]
+	delete(m.waiters, key)
+	m.mutex.Unlock()
+
+	if found {
+		w.Close()
+	}
+	return found
+}
+
+// Len returns the number of the keys in flight.
+func (m *waiterMap) Len() (n int) {
+	m.mutex.Lock()
+	n = len(m.waiters)
+	m.mutex.Unlock()
+	return
+}
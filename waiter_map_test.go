@@ -0,0 +1,79 @@
+// Copyright 2021 The ZKits Project Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package runner
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestWaiterMap(t *testing.T) {
+	m := NewWaiterMap()
+
+	w1, ok := m.Wait("a")
+	if !ok {
+		t.Fatal("WaiterMap.Wait(): not new")
+	}
+	w2, ok := m.Wait("a")
+	if ok || w2.Channel() != w1.Channel() {
+		t.Fatal("WaiterMap.Wait(): not shared")
+	}
+	if _, ok := m.Wait("b"); !ok {
+		t.Fatal("WaiterMap.Wait(): not new")
+	}
+	if n := m.Len(); n != 2 {
+		t.Fatalf("WaiterMap.Len(): %d", n)
+	}
+
+	if !m.Done("a") {
+		t.Fatal("WaiterMap.Done(): false")
+	}
+	w2.Wait()
+	if m.Done("a") || m.Done("c") {
+		t.Fatal("WaiterMap.Done(): true")
+	}
+	if n := m.Len(); n != 1 {
+		t.Fatalf("WaiterMap.Len(): %d", n)
+	}
+	if _, ok := m.Wait("a"); !ok {
+		t.Fatal("WaiterMap.Wait(): not new")
+	}
+}
+
+func TestWaiterMap_Concurrent(t *testing.T) {
+	m := NewWaiterMap()
+	var fetches int32
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w, ok := m.Wait("key")
+			if ok {
+				atomic.AddInt32(&fetches, 1)
+				m.Done("key")
+			}
+			w.Wait()
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&fetches); n < 1 {
+		t.Fatalf("WaiterMap: %d", n)
+	}
+	if n := m.Len(); n != 0 {
+		t.Fatalf("WaiterMap.Len(): %d", n)
+	}
+}
@@ -15,6 +15,7 @@
 package runner
 
 import (
+	"context"
 	"sync"
 	"testing"
 	"time"
@@ -65,6 +66,82 @@ func TestCloseableWaiter(t *testing.T) {
 	}
 }
 
+func TestWaitTimeout(t *testing.T) {
+	waiter := NewCloseableWaiter()
+	if _, ok := waiter.(TimeoutWaiter); !ok {
+		t.Fatal("NewCloseableWaiter(): not a TimeoutWaiter")
+	}
+	if WaitTimeout(waiter, time.Millisecond*10) {
+		t.Fatal("WaitTimeout(): true")
+	}
+	if WaitTimeout(waiter.Waiter(), 0) {
+		t.Fatal("WaitTimeout(): true")
+	}
+
+	time.AfterFunc(time.Millisecond*10, waiter.Close)
+	if !waiter.Waiter().(TimeoutWaiter).WaitTimeout(time.Hour) {
+		t.Fatal("TimeoutWaiter.WaitTimeout(): false")
+	}
+	if !WaitTimeout(waiter, 0) {
+		t.Fatal("WaitTimeout(): false")
+	}
+
+	duplex := NewDuplexWaiter()
+	if _, ok := duplex.(TimeoutWaiter); !ok {
+		t.Fatal("NewDuplexWaiter(): not a TimeoutWaiter")
+	}
+	if _, ok := duplex.Waiter().(TimeoutWaiter); !ok {
+		t.Fatal("DuplexWaiter.Waiter(): not a TimeoutWaiter")
+	}
+	if WaitTimeout(duplex, time.Millisecond*10) {
+		t.Fatal("WaitTimeout(): true")
+	}
+	duplex.Close()
+	if !WaitTimeout(duplex, time.Hour) {
+		t.Fatal("WaitTimeout(): false")
+	}
+}
+
+func TestWaitContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	waiter := NewCloseableWaiter()
+	if _, ok := waiter.(ContextWaiter); !ok {
+		t.Fatal("NewCloseableWaiter(): not a ContextWaiter")
+	}
+	if err := WaitContext(ctx, waiter); err != context.Canceled {
+		t.Fatalf("WaitContext(): %v", err)
+	}
+	waiter.Close()
+	// The closed waiter takes precedence over the done context.
+	if err := WaitContext(ctx, waiter.Waiter()); err != nil {
+		t.Fatalf("WaitContext(): %s", err)
+	}
+
+	duplex := NewDuplexWaiter()
+	if _, ok := duplex.Waiter().(ContextWaiter); !ok {
+		t.Fatal("DuplexWaiter.Waiter(): not a ContextWaiter")
+	}
+	timeout, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	if err := WaitContext(timeout, duplex); err != context.DeadlineExceeded {
+		t.Fatalf("WaitContext(): %v", err)
+	}
+	time.AfterFunc(time.Millisecond*10, duplex.Close)
+	if err := WaitContext(context.Background(), duplex); err != nil {
+		t.Fatalf("WaitContext(): %s", err)
+	}
+	if err := WaitContext(ctx, duplex); err != nil {
+		t.Fatalf("WaitContext(): %s", err)
+	}
+
+	// The waiter does not implement the ContextWaiter interface.
+	if err := WaitContext(ctx, EmptyReceiptableWaiter()); err != nil {
+		t.Fatalf("WaitContext(): %s", err)
+	}
+}
+
 func TestDuplexWaiter(t *testing.T) {
 	waiter := NewDuplexWaiter()
 	if waiter == nil {
@@ -111,3 +188,79 @@ func TestDuplexWaiter(t *testing.T) {
 		t.Fatalf("NewDuplexWaiter: %d %d %d %d", m, n, p, q)
 	}
 }
+
+func TestDuplexWaiter_Reset(t *testing.T) {
+	waiter := NewResettableDuplexWaiter()
+	c, d := waiter.Channel(), waiter.DoneChannel()
+
+	// The coroutines blocked on the previous cycle are released by the reset.
+	released := new(sync.WaitGroup)
+	released.Add(2)
+	go func() {
+		defer released.Done()
+		waiter.Wait()
+	}()
+	go func() {
+		defer released.Done()
+		waiter.WaitDone()
+	}()
+	time.Sleep(time.Millisecond * 10)
+	waiter.Reset()
+	released.Wait()
+	<-c
+	<-d
+
+	select {
+	case <-waiter.Channel():
+		t.Fatal("DuplexWaiter.Reset(): closed")
+	case <-waiter.DoneChannel():
+		t.Fatal("DuplexWaiter.Reset(): done")
+	default:
+	}
+
+	// The waiter is reusable for the next cycle.
+	go func(w ReceiptableWaiter) {
+		w.Wait()
+		w.Done()
+	}(waiter.Waiter())
+	waiter.CloseAndWaitDone()
+	waiter.Close()
+	waiter.Done()
+}
+
+func TestDuplexWaiter_ResetStress(t *testing.T) {
+	waiter := NewResettableDuplexWaiter()
+	stop := make(chan struct{})
+	wg := new(sync.WaitGroup)
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func(w ReceiptableWaiter) {
+			defer wg.Done()
+			for {
+				select {
+				case <-stop:
+					return
+				case <-w.Channel():
+					w.Done()
+				}
+			}
+		}(waiter.Waiter())
+	}
+	closers := new(sync.WaitGroup)
+	for i := 0; i < 2; i++ {
+		closers.Add(1)
+		go func() {
+			defer closers.Done()
+			for j := 0; j < 200; j++ {
+				waiter.CloseAndWaitDone()
+			}
+		}()
+	}
+	for j := 0; j < 200; j++ {
+		waiter.Reset()
+	}
+	closers.Wait()
+	close(stop)
+	wg.Wait()
+}